@@ -1,13 +1,24 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/Hodik/geo-tracker-be/database"
 	"github.com/Hodik/geo-tracker-be/dbconn"
 	docs "github.com/Hodik/geo-tracker-be/docs"
+	"github.com/Hodik/geo-tracker-be/gateway"
 	"github.com/Hodik/geo-tracker-be/middleware"
+	"github.com/Hodik/geo-tracker-be/realtime"
+	"github.com/Hodik/geo-tracker-be/simulator"
 	"github.com/Hodik/geo-tracker-be/views"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -19,7 +30,7 @@ import (
 // swagger embed files
 
 func main() {
-	mode := flag.String("mode", "api", "Mode to run: api or worker or migrator")
+	mode := flag.String("mode", "api", "Mode to run: api or worker or gateway or migrator or simulator")
 
 	flag.Parse()
 
@@ -31,7 +42,13 @@ func main() {
 		runApi()
 	case "worker":
 		setupApp()
-		PollDevices(dbconn.GetDB())
+		runWorker()
+	case "gateway":
+		setupApp()
+		runGateway()
+	case "simulator":
+		setupApp()
+		runSimulator()
 	case "migrator":
 		setupMigrator()
 		database.SetupDB()
@@ -40,7 +57,39 @@ func main() {
 	}
 }
 
+func runGateway() {
+	addr := os.Getenv("GATEWAY_ADDR")
+
+	if addr == "" {
+		addr = ":5023"
+	}
+
+	gateway.Run(dbconn.GetDB(), addr)
+}
+
+// runSimulator moves virtual devices until interrupted, for demos, load tests and reproducing alerts without hardware
+func runSimulator() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	options, err := simulator.LoadOptions()
+	if err != nil {
+		log.Fatalln("Invalid simulator options:", err)
+	}
+
+	if err := simulator.Run(ctx, dbconn.GetDB(), options); err != nil {
+		log.Fatalln("Simulator failed:", err)
+	}
+}
+
+// In-flight requests get this long to complete on shutdown
+const apiShutdownTimeout = 10 * time.Second
+
+// runApi serves the API until SIGTERM, then stops the stream listener and drains in-flight requests
 func runApi() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
 	r := gin.Default()
 
 	docs.SwaggerInfo.BasePath = ""
@@ -51,6 +100,36 @@ func runApi() {
 	// Ping route
 	r.GET("/ping", views.Ping)
 
+	// Push ingestion authenticated by device token instead of JWT
+	ingest := r.Group("/ingest")
+	ingest.Use(middleware.DBMiddleware(dbconn.GetDB()))
+	{
+		ingest.GET("/osmand", views.OsmAndIngest)
+		ingest.POST("/osmand", views.OsmAndIngest)
+		ingest.POST("/osmand/batch", views.OsmAndIngestBatch)
+	}
+
+	// Callbacks authenticated by the signature of the sender
+	webhooks := r.Group("/webhooks")
+	webhooks.Use(middleware.DBMiddleware(dbconn.GetDB()))
+	{
+		webhooks.POST("/twilio/sms", views.TwilioSMSWebhook)
+		webhooks.POST("/twilio/status", views.TwilioStatusWebhook)
+	}
+
+	// Streams authenticate like the API, or with a ticket in the query for browsers
+	go realtime.Listen(ctx, os.Getenv("DB_STRING"))
+
+	stream := r.Group("/api/stream")
+	stream.Use(middleware.DBMiddleware(dbconn.GetDB()))
+	stream.Use(middleware.StreamTicket)
+	stream.Use(middleware.UnlessAuthenticated(middleware.EnsureValidToken()))
+	stream.Use(middleware.UnlessAuthenticated(middleware.FetchOrCreateUser))
+	{
+		stream.GET("", views.StreamEvents)
+		stream.GET("/ws", views.StreamWebSocket)
+	}
+
 	// API routes with middlewares
 	api := r.Group("/api")
 	api.Use(middleware.EnsureValidToken())
@@ -81,8 +160,40 @@ func runApi() {
 		{
 			devices.GET("", views.GetGPSDevices)
 			devices.POST("", views.CreateGPSDevice)
+			devices.POST("/import", views.ImportGPSDevices)
+			devices.GET("/attention", views.GetDevicesNeedingAttention)
 			devices.PATCH("/:id", views.UpdateGPSDevice)
 			devices.GET("/:id", views.GetGPSDevice)
+			devices.GET("/:id/locations", views.GetGPSDeviceLocations)
+			devices.POST("/:id/import", views.ImportGPSDeviceTrack)
+			devices.GET("/:id/trips", views.GetGPSDeviceTrips)
+			devices.GET("/:id/stops", views.GetGPSDeviceStops)
+			devices.GET("/:id/stats", views.GetGPSDeviceStats)
+			devices.GET("/:id/geofences", views.GetGPSDeviceGeofences)
+			devices.POST("/:id/geofences", views.CreateGPSDeviceGeofence)
+			devices.DELETE("/:id/geofences/:geofence_id", views.DeleteGPSDeviceGeofence)
+			devices.GET("/:id/geofence-transitions", views.GetGPSDeviceGeofenceTransitions)
+			devices.GET("/:id/commands/catalog", views.GetGPSDeviceCommandCatalog)
+			devices.GET("/:id/commands", views.GetGPSDeviceCommands)
+			devices.POST("/:id/commands", views.CreateGPSDeviceCommand)
+			devices.GET("/:id/shares", views.GetGPSDeviceShares)
+			devices.POST("/:id/shares", views.CreateGPSDeviceShare)
+			devices.PATCH("/:id/shares/:share_id", views.UpdateGPSDeviceShare)
+			devices.DELETE("/:id/shares/:share_id", views.DeleteGPSDeviceShare)
+		}
+
+		deviceGroups := api.Group("/device-groups")
+		{
+			deviceGroups.GET("", views.GetDeviceGroups)
+			deviceGroups.POST("", views.CreateDeviceGroup)
+			deviceGroups.GET("/:id", views.GetDeviceGroup)
+			deviceGroups.PATCH("/:id", views.UpdateDeviceGroup)
+			deviceGroups.DELETE("/:id", views.DeleteDeviceGroup)
+			deviceGroups.POST("/:id/add-devices", views.AddDeviceGroupDevices)
+			deviceGroups.POST("/:id/remove-devices", views.RemoveDeviceGroupDevices)
+			deviceGroups.POST("/:id/bulk", views.BulkDeviceGroupAction)
+			deviceGroups.GET("/:id/positions", views.GetDeviceGroupPositions)
+			deviceGroups.GET("/:id/status", views.GetDeviceGroupStatus)
 		}
 
 		communities := api.Group("/communities")
@@ -113,6 +224,14 @@ func runApi() {
 			communityInvites.DELETE("/:id", views.DeleteCommunityInvite)
 		}
 
+		providerAccounts := api.Group("/provider-accounts")
+		{
+			providerAccounts.GET("", views.GetProviderAccounts)
+			providerAccounts.POST("", views.CreateProviderAccount)
+			providerAccounts.PATCH("/:id", views.UpdateProviderAccount)
+			providerAccounts.DELETE("/:id", views.DeleteProviderAccount)
+		}
+
 		events := api.Group("/events")
 		{
 			events.POST("", views.CreateEvent)
@@ -130,7 +249,37 @@ func runApi() {
 			comments.PATCH("/:id", views.UpdateComment)
 			comments.DELETE("/:id", views.DeleteComment)
 		}
+
+		api.POST("/stream/ticket", views.CreateStreamTicket)
+	}
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: r,
+		// Requests see the shutdown, so open streams end instead of holding the server up
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalln("API server failed:", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down api")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), apiShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Failed to drain api requests", err)
 	}
 
-	r.Run()
+	log.Println("API stopped")
 }
@@ -1,26 +1,33 @@
 package main
 
 import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/commands"
 	"github.com/Hodik/geo-tracker-be/messaging"
+	"gorm.io/gorm"
 )
 
-const (
-	GetCurrentLocationCommand string = "999"
-	ResetCommand              string = "1122"
-	RestartCommand            string = "SYSRST#"
-)
+const commandDispatchInterval = 5 * time.Second
 
-func SendGetDeviceLocationCommand(number string) (err error) {
-	_, err = messaging.Send(string(number), GetCurrentLocationCommand)
-	return err
-}
+func ScheduleCommandDispatch(ctx context.Context, db *gorm.DB) {
+	if !messaging.IsConfigured() {
+		log.Println("SMS is not configured, device commands can't be sent")
+		if err := commands.FailUnsent(db); err != nil {
+			log.Default().Println("Failed to mark unsent device commands as failed", err)
+		}
+		return
+	}
 
-func SendResetCommand(number string) (err error) {
-	_, err = messaging.Send(string(number), ResetCommand)
-	return err
-}
+	for {
+		if err := commands.Dispatch(db); err != nil {
+			log.Default().Println("Failed to dispatch device commands", err)
+		}
 
-func SendRestartCommand(number string) (err error) {
-	_, err = messaging.Send(string(number), RestartCommand)
-	return err
+		if !sleepContext(ctx, commandDispatchInterval) {
+			return
+		}
+	}
 }
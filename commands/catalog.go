@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/Hodik/geo-tracker-be/models"
+)
+
+var ErrUnknownCommand = errors.New("command is not supported by the device")
+
+const (
+	Locate  = "locate"
+	Reset   = "reset"
+	Restart = "restart"
+	Status  = "status"
+)
+
+// Definition is a command a device model understands, sent to the device as an SMS with Payload as text
+type Definition struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Payload     string `json:"-"`
+	// Retryable commands are sent again when the device doesn't answer. Running them twice is harmless,
+	// unlike a reset or a restart the device may have carried out with only its reply lost.
+	Retryable bool `json:"retryable"`
+	// Reply matches the answer of the device, other texts it sends don't acknowledge the command
+	Reply *regexp.Regexp `json:"-"`
+}
+
+var (
+	positionReply = regexp.MustCompile(`(?i)maps|\blat(itude)?\b`)
+	statusReply   = regexp.MustCompile(`(?i)\b(battery|bat|power|gsm|gprs|gps)\b`)
+	resetReply    = regexp.MustCompile(`(?i)factory|restore|\breset\b`)
+	restartReply  = regexp.MustCompile(`(?i)restart|reboot|\bok\b`)
+)
+
+var catalog = map[models.DeviceProviderType][]Definition{
+	models.Provider365GPS: {
+		{Name: Locate, Description: "Reply with the current position", Payload: "999", Retryable: true, Reply: positionReply},
+		{Name: Reset, Description: "Reset the tracker to factory settings", Payload: "1122", Reply: resetReply},
+		{Name: Restart, Description: "Restart the tracker", Payload: "SYSRST#", Reply: restartReply},
+	},
+	models.ProviderGT06: {
+		{Name: Locate, Description: "Reply with the current position", Payload: "WHERE#", Retryable: true, Reply: positionReply},
+		{Name: Status, Description: "Reply with battery, GPS and GSM status", Payload: "STATUS#", Retryable: true, Reply: statusReply},
+		{Name: Reset, Description: "Reset the tracker to factory settings", Payload: "FACTORY#", Reply: resetReply},
+		{Name: Restart, Description: "Restart the tracker", Payload: "RESET#", Reply: restartReply},
+	},
+}
+
+func Catalog(provider models.DeviceProviderType) []Definition {
+	definitions := catalog[provider]
+	if definitions == nil {
+		return []Definition{}
+	}
+
+	return definitions
+}
+
+func Find(provider models.DeviceProviderType, name string) (*Definition, error) {
+	for _, definition := range catalog[provider] {
+		if definition.Name == name {
+			return &definition, nil
+		}
+	}
+
+	return nil, ErrUnknownCommand
+}
@@ -0,0 +1,252 @@
+package commands
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/config"
+	"github.com/Hodik/geo-tracker-be/messaging"
+	"github.com/Hodik/geo-tracker-be/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSMSNotConfigured is returned when commands are queued while nothing would ever send them
+var ErrSMSNotConfigured = errors.New("SMS is not configured, commands can't be sent")
+
+// Queue records a command for the device, the worker sends it on its next dispatch
+func Queue(db *gorm.DB, device *models.GPSDevice, definition *Definition, creator *models.User) (*models.DeviceCommand, error) {
+	if !messaging.IsConfigured() {
+		return nil, ErrSMSNotConfigured
+	}
+
+	if device.Number == nil {
+		return nil, errors.New("device has no phone number")
+	}
+
+	now := time.Now().UTC()
+	command := &models.DeviceCommand{
+		DeviceID:      device.ID,
+		Name:          definition.Name,
+		Payload:       definition.Payload,
+		Retryable:     definition.Retryable,
+		Status:        models.CommandPending,
+		NextAttemptAt: &now,
+		CreatedByID:   creator.ID,
+	}
+
+	if err := db.Create(command).Error; err != nil {
+		return nil, err
+	}
+
+	return command, nil
+}
+
+// FailUnsent fails the commands still waiting to be sent, for when SMS is not configured and they never would be
+func FailUnsent(db *gorm.DB) error {
+	return db.Model(&models.DeviceCommand{}).
+		Where("status = ?", models.CommandPending).
+		Updates(map[string]interface{}{"status": models.CommandFailed, "next_attempt_at": nil, "last_error": ErrSMSNotConfigured.Error()}).Error
+}
+
+// A command claimed for sending this long ago belongs to a worker that died while sending it
+const sendingTimeout = 5 * time.Minute
+
+// Dispatch sends every due command and queues again the retryable ones the device never answered.
+// Commands are claimed before sending, so several workers never send the same command twice.
+func Dispatch(db *gorm.DB) error {
+	conf := config.GetConfig(db)
+	now := time.Now().UTC()
+	unanswered := now.Add(-time.Duration(conf.CommandAckTimeoutMinutes) * time.Minute)
+	awaiting := []models.DeviceCommandStatus{models.CommandSent, models.CommandDelivered}
+
+	if err := db.Model(&models.DeviceCommand{}).
+		Where("status IN ? AND sent_at < ? AND (attempts >= ? OR NOT retryable)", awaiting, unanswered, conf.CommandMaxAttempts).
+		Updates(map[string]interface{}{"status": models.CommandFailed, "last_error": "no acknowledgment from device"}).Error; err != nil {
+		return err
+	}
+
+	if err := db.Model(&models.DeviceCommand{}).
+		Where("status IN ? AND sent_at < ?", awaiting, unanswered).
+		Updates(map[string]interface{}{"status": models.CommandPending, "next_attempt_at": now}).Error; err != nil {
+		return err
+	}
+
+	// Whether the SMS of an interrupted send went out is unknown, only retryable commands are sent again
+	interrupted := now.Add(-sendingTimeout)
+
+	if err := db.Model(&models.DeviceCommand{}).
+		Where("status = ? AND updated_at < ? AND NOT retryable", models.CommandSending, interrupted).
+		Updates(map[string]interface{}{"status": models.CommandFailed, "last_error": "sending was interrupted, the device may have received the command"}).Error; err != nil {
+		return err
+	}
+
+	if err := db.Model(&models.DeviceCommand{}).
+		Where("status = ? AND updated_at < ?", models.CommandSending, interrupted).
+		Updates(map[string]interface{}{"status": models.CommandPending, "next_attempt_at": now}).Error; err != nil {
+		return err
+	}
+
+	for {
+		dispatched, err := dispatchNext(db, conf)
+		if err != nil {
+			return err
+		}
+
+		if !dispatched {
+			return nil
+		}
+	}
+}
+
+func dispatchNext(db *gorm.DB, conf *models.Config) (bool, error) {
+	command, device, err := claimNext(db)
+	if err != nil || command == nil {
+		return false, err
+	}
+
+	if device.Number == nil {
+		fail(command, conf, "device has no phone number", true)
+		return true, db.Save(command).Error
+	}
+
+	// Sent outside of any transaction, a slow SMS provider must not keep rows locked
+	sid, err := messaging.Send(*device.Number, command.Payload)
+
+	if err != nil {
+		// A failed request may still have reached the provider, only retryable commands risk a second SMS
+		fail(command, conf, err.Error(), !command.Retryable)
+		return true, db.Save(command).Error
+	}
+
+	now := time.Now().UTC()
+	command.Status = models.CommandSent
+	command.MessageSID = sid
+	command.SentAt = &now
+	command.DeliveredAt = nil
+	command.NextAttemptAt = nil
+	command.LastError = nil
+
+	return true, db.Save(command).Error
+}
+
+// claimNext marks the next due command as being sent. The row is only locked for the claim, other
+// workers skip it while locked and don't pick it once claimed.
+func claimNext(db *gorm.DB) (*models.DeviceCommand, *models.GPSDevice, error) {
+	var command models.DeviceCommand
+	var device models.GPSDevice
+	claimed := false
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.CommandPending, time.Now().UTC()).
+			Order("next_attempt_at").Limit(1).Find(&command)
+
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		if err := tx.Where("id = ?", command.DeviceID).First(&device).Error; err != nil {
+			return err
+		}
+
+		command.Attempts++
+		command.Status = models.CommandSending
+		claimed = true
+
+		return tx.Save(&command).Error
+	})
+
+	if err != nil || !claimed {
+		return nil, nil, err
+	}
+
+	return &command, &device, nil
+}
+
+// fail schedules another attempt with exponential backoff, or gives up once attempts are exhausted
+func fail(command *models.DeviceCommand, conf *models.Config, reason string, permanent bool) {
+	command.LastError = &reason
+
+	if permanent || command.Attempts >= int(conf.CommandMaxAttempts) {
+		command.Status = models.CommandFailed
+		command.NextAttemptAt = nil
+		return
+	}
+
+	backoff := time.Duration(conf.CommandRetrySeconds) * time.Second << (command.Attempts - 1)
+	next := time.Now().UTC().Add(backoff)
+
+	command.Status = models.CommandPending
+	command.NextAttemptAt = &next
+}
+
+// Trackers text alarms on their own, with the position attached, those are never a reply
+var alarmText = regexp.MustCompile(`(?i)\b(alarm|sos)\b`)
+
+// Acknowledge attributes a reply of the device to the oldest command waiting for an answer that the reply
+// matches. Texts matching no command, such as alarms, leave every command waiting.
+func Acknowledge(db *gorm.DB, device *models.GPSDevice, body string) (*models.DeviceCommand, error) {
+	if alarmText.MatchString(body) {
+		return nil, nil
+	}
+
+	var waiting []models.DeviceCommand
+	if err := db.Where("device_id = ? AND status IN ?", device.ID, []models.DeviceCommandStatus{models.CommandSent, models.CommandDelivered}).
+		Order("sent_at").Find(&waiting).Error; err != nil {
+		return nil, err
+	}
+
+	var command *models.DeviceCommand
+	for i := range waiting {
+		definition, err := Find(device.Provider, waiting[i].Name)
+		if err == nil && definition.Reply.MatchString(body) {
+			command = &waiting[i]
+			break
+		}
+	}
+
+	if command == nil {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	command.Status = models.CommandAcknowledged
+	command.AcknowledgedAt = &now
+	command.Response = &body
+
+	if err := db.Save(command).Error; err != nil {
+		return nil, err
+	}
+
+	return command, nil
+}
+
+// UpdateDeliveryStatus applies a Twilio status callback to the command sent with the message
+func UpdateDeliveryStatus(db *gorm.DB, sid string, status string, errorCode string) error {
+	var command models.DeviceCommand
+	result := db.Where("message_sid = ? AND status = ?", sid, models.CommandSent).Limit(1).Find(&command)
+
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	switch status {
+	case "delivered":
+		now := time.Now().UTC()
+		command.Status = models.CommandDelivered
+		command.DeliveredAt = &now
+	case "undelivered", "failed":
+		// The device never received the SMS, sending it again is safe for any command
+		reason := "sms " + status
+		if errorCode != "" {
+			reason += ": error " + errorCode
+		}
+		fail(&command, config.GetConfig(db), reason, false)
+	default:
+		return nil
+	}
+
+	return db.Save(&command).Error
+}
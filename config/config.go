@@ -13,6 +13,8 @@ func LoadConfig(db *gorm.DB) *models.Config {
 	if result.Error != nil {
 		panic(result.Error)
 	}
+
+	conf.ApplyMinimums()
 	return conf
 }
 
@@ -0,0 +1,199 @@
+package coordination
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"hash/fnv"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	heartbeatInterval = 10 * time.Second
+	// A replica missing three heartbeats is considered dead and its devices move to the others
+	replicaTimeout = 3 * heartbeatInterval
+	// Arbitrary key of the session advisory lock held by the leader
+	leaderLockKey int64 = 0x67656f747261636b
+)
+
+// Replica registers the worker process in the cluster. Devices are spread over live replicas with
+// rendezvous hashing and a single leader, holding a Postgres advisory lock, runs scheduled jobs.
+type Replica struct {
+	ID string
+	db *gorm.DB
+
+	mu         sync.RWMutex
+	members    []string
+	leaderConn *sql.Conn
+}
+
+func NewReplica(db *gorm.DB) *Replica {
+	hostname, _ := os.Hostname()
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+
+	return &Replica{ID: hostname + "-" + hex.EncodeToString(suffix), db: db, members: []string{}}
+}
+
+// Run keeps the replica registered until ctx is cancelled, then leaves the cluster so the remaining
+// replicas take over its devices right away.
+func (r *Replica) Run(ctx context.Context) {
+	for {
+		r.heartbeat(ctx)
+
+		select {
+		case <-ctx.Done():
+			r.leave()
+			return
+		case <-time.After(heartbeatInterval):
+		}
+	}
+}
+
+// Join registers the replica before any work starts so the first poll cycle is already partitioned
+func (r *Replica) Join(ctx context.Context) {
+	r.heartbeat(ctx)
+}
+
+func (r *Replica) heartbeat(ctx context.Context) {
+	now := time.Now().UTC()
+
+	replica := models.WorkerReplica{ID: r.ID, HeartbeatAt: now}
+	if err := r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&replica).Error; err != nil {
+		log.Default().Println("Failed to send worker heartbeat", err)
+	}
+
+	var members []string
+	if err := r.db.Model(&models.WorkerReplica{}).Where("heartbeat_at > ?", now.Add(-replicaTimeout)).Order("id").Pluck("id", &members).Error; err != nil {
+		log.Default().Println("Failed to load worker replicas", err)
+	} else {
+		r.setMembers(members)
+	}
+
+	if err := r.db.Where("heartbeat_at < ?", now.Add(-10*replicaTimeout)).Delete(&models.WorkerReplica{}).Error; err != nil {
+		log.Default().Println("Failed to remove dead worker replicas", err)
+	}
+
+	r.campaign(ctx)
+}
+
+func (r *Replica) setMembers(members []string) {
+	sort.Strings(members)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(members) != len(r.members) {
+		log.Default().Println("Worker replicas changed, now", len(members), "alive")
+	}
+
+	r.members = members
+}
+
+// Owns reports whether the device is polled by this replica
+func (r *Replica) Owns(id uuid.UUID) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.members) == 0 {
+		return true
+	}
+
+	var owner string
+	var best uint64
+	for _, member := range r.members {
+		hash := fnv.New64a()
+		hash.Write([]byte(member))
+		hash.Write(id[:])
+
+		if score := hash.Sum64(); owner == "" || score > best {
+			owner, best = member, score
+		}
+	}
+
+	return owner == r.ID
+}
+
+// Claim leases the device for ttl, false means another replica polled it recently
+func (r *Replica) Claim(id uuid.UUID, ttl time.Duration) (bool, error) {
+	result := r.db.Exec(`
+		INSERT INTO device_leases (device_id, worker_id, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (device_id) DO UPDATE SET worker_id = EXCLUDED.worker_id, expires_at = EXCLUDED.expires_at
+		WHERE device_leases.expires_at < NOW() OR device_leases.worker_id = EXCLUDED.worker_id
+	`, id, r.ID, time.Now().UTC().Add(ttl))
+
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *Replica) IsLeader() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.leaderConn != nil
+}
+
+// campaign takes the leader lock when it is free. The lock lives as long as the connection, so a
+// replica that dies releases it with its connection.
+func (r *Replica) campaign(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.leaderConn != nil {
+		if err := r.leaderConn.PingContext(ctx); err != nil {
+			log.Default().Println("Lost worker leadership", err)
+			r.leaderConn.Close()
+			r.leaderConn = nil
+		}
+		return
+	}
+
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", leaderLockKey).Scan(&acquired); err != nil || !acquired {
+		conn.Close()
+		return
+	}
+
+	log.Default().Println("Worker", r.ID, "is now the leader")
+	r.leaderConn = conn
+}
+
+func (r *Replica) leave() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Pooled connections outlive Close, the lock has to be released explicitly
+	if r.leaderConn != nil {
+		r.leaderConn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", leaderLockKey)
+		r.leaderConn.Close()
+		r.leaderConn = nil
+	}
+
+	if err := r.db.Where("worker_id = ?", r.ID).Delete(&models.DeviceLease{}).Error; err != nil {
+		log.Default().Println("Failed to release device leases", err)
+	}
+
+	if err := r.db.Where("id = ?", r.ID).Delete(&models.WorkerReplica{}).Error; err != nil {
+		log.Default().Println("Failed to leave worker replicas", err)
+	}
+}
@@ -9,6 +9,8 @@ import (
 
 	"github.com/Hodik/geo-tracker-be/dbconn"
 	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/secrets"
+	"github.com/google/uuid"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -79,6 +81,60 @@ func SetupDB() {
 		panic(err)
 	}
 
+	providers := make([]string, len(models.DeviceProviderTypes))
+	for i, provider := range models.DeviceProviderTypes {
+		providers[i] = string(provider)
+	}
+
+	if err = CreateEnumType("device_provider", providers); err != nil {
+		panic(err)
+	}
+
+	triggers := make([]string, len(models.GeofenceTriggers))
+	for i, trigger := range models.GeofenceTriggers {
+		triggers[i] = string(trigger)
+	}
+
+	if err = CreateEnumType("geofence_trigger", triggers); err != nil {
+		panic(err)
+	}
+
+	commandStatuses := make([]string, len(models.DeviceCommandStatuses))
+	for i, status := range models.DeviceCommandStatuses {
+		commandStatuses[i] = string(status)
+	}
+
+	if err = CreateEnumType("device_command_status", commandStatuses); err != nil {
+		panic(err)
+	}
+
+	deviceStatuses := make([]string, len(models.DeviceStatuses))
+	for i, status := range models.DeviceStatuses {
+		deviceStatuses[i] = string(status)
+	}
+
+	if err = CreateEnumType("device_status", deviceStatuses); err != nil {
+		panic(err)
+	}
+
+	filterReasons := make([]string, len(models.LocationFilterReasons))
+	for i, reason := range models.LocationFilterReasons {
+		filterReasons[i] = string(reason)
+	}
+
+	if err = CreateEnumType("location_filter_reason", filterReasons); err != nil {
+		panic(err)
+	}
+
+	deviceRoles := make([]string, len(models.DeviceShareRoles))
+	for i, role := range models.DeviceShareRoles {
+		deviceRoles[i] = string(role)
+	}
+
+	if err = CreateEnumType("device_role", deviceRoles); err != nil {
+		panic(err)
+	}
+
 	log.Println("Created DB types")
 
 	err = db.AutoMigrate(&models.GPSDevice{},
@@ -95,6 +151,21 @@ func SetupDB() {
 		&models.CommunityInvite{},
 		&models.CommunityMember{},
 		&models.MediaFile{},
+		&models.Trip{},
+		&models.Stop{},
+		&models.TripSegmentation{},
+		&models.GeofenceRule{},
+		&models.GeofenceTransition{},
+		&models.GeofenceState{},
+		&models.DeviceCommand{},
+		&models.WorkerReplica{},
+		&models.DeviceLease{},
+		&models.ProviderAccount{},
+		&models.DeviceShare{},
+		&models.DeviceGroup{},
+		&models.DeviceDailyStats{},
+		&models.DeviceStatsRollup{},
+		&models.StreamTicket{},
 	)
 
 	if err != nil {
@@ -107,6 +178,18 @@ func SetupDB() {
 		log.Panicln("failed to create DB indexes: ", err)
 	}
 
+	err = BackfillLocationFixTimes()
+
+	if err != nil {
+		log.Panicln("failed to backfill location fix times: ", err)
+	}
+
+	err = EncryptCredentials()
+
+	if err != nil {
+		log.Panicln("failed to encrypt credentials: ", err)
+	}
+
 	result = db.FirstOrCreate(&migration, models.Migration{Status: false})
 
 	if result.Error != nil {
@@ -172,6 +255,76 @@ func CreateDBIndexes() error {
 	return nil
 }
 
+// BackfillLocationFixTimes sets the fix time of locations stored before it was tracked to their insert time.
+func BackfillLocationFixTimes() error {
+	result := db.Exec("UPDATE gps_locations SET fix_time = created_at WHERE fix_time IS NULL")
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		log.Printf("Backfilled fix time of %d locations", result.RowsAffected)
+	}
+
+	return nil
+}
+
+type credentialColumn struct {
+	table  string
+	column string
+}
+
+var credentialColumns = []credentialColumn{
+	{"gps_devices", "password"},
+	{"gps_devices", "api_cookie"},
+	{"provider_accounts", "password"},
+	{"provider_accounts", "api_cookie"},
+}
+
+// EncryptCredentials encrypts credentials stored in plaintext and rewraps the ones encrypted with a key
+// that is no longer primary, so retired keys can be dropped once the migrator ran.
+func EncryptCredentials() error {
+	if !secrets.Configured() {
+		log.Println("Credential encryption keys are not configured, leaving stored credentials as they are")
+		return nil
+	}
+
+	for _, c := range credentialColumns {
+		var rows []struct {
+			ID    uuid.UUID
+			Value string
+		}
+
+		if err := db.Table(c.table).Select("id, " + c.column + " AS value").Where(c.column + " IS NOT NULL").Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		rewrapped := 0
+		for _, row := range rows {
+			if !secrets.NeedsRewrap(row.Value) {
+				continue
+			}
+
+			value, err := secrets.Rewrap(row.Value)
+			if err != nil {
+				return fmt.Errorf("%s.%s of %s: %w", c.table, c.column, row.ID, err)
+			}
+
+			if err := db.Table(c.table).Where("id = ?", row.ID).UpdateColumn(c.column, value).Error; err != nil {
+				return err
+			}
+			rewrapped++
+		}
+
+		if rewrapped > 0 {
+			log.Printf("Encrypted %d values of %s.%s with the primary key", rewrapped, c.table, c.column)
+		}
+	}
+
+	return nil
+}
+
 func CreateEnumType(enumName string, values []string) error {
 	// Check if the enum type already exists
 	query := fmt.Sprintf("SELECT 1 FROM pg_type WHERE typname = '%s';", enumName)
@@ -197,7 +350,14 @@ func CreateEnumType(enumName string, values []string) error {
 		return result.Error
 
 	default:
-		// Enum type already exists, so do nothing
+		// Enum type already exists, make sure newly added values are present
+		for _, value := range values {
+			if err := db.Exec(fmt.Sprintf("ALTER TYPE %s ADD VALUE IF NOT EXISTS '%s';", enumName, value)).Error; err != nil {
+				log.Printf("Error adding value %s to enum type %s: %v", value, enumName, err)
+				return err
+			}
+		}
+
 		log.Printf("Enum type %s already exists", enumName)
 		return nil
 	}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/config"
+	"github.com/Hodik/geo-tracker-be/coordination"
+	"github.com/Hodik/geo-tracker-be/health"
+	"gorm.io/gorm"
+)
+
+// ScheduleHealth periodically marks silent devices offline and notifies their trackers.
+func ScheduleHealth(ctx context.Context, db *gorm.DB, replica *coordination.Replica) {
+	for {
+		conf := config.GetConfig(db)
+
+		// The status update is conditional, but only the leader runs it so trackers aren't notified twice
+		if replica.IsLeader() {
+			if err := health.MarkOffline(db); err != nil {
+				log.Default().Println("Failed to mark offline devices", err)
+			}
+		}
+
+		if !sleepContext(ctx, time.Duration(conf.HealthJobMinutes)*time.Minute) {
+			return
+		}
+	}
+}
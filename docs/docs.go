@@ -1180,23 +1180,23 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/devices": {
+        "/api/device-groups": {
             "get": {
-                "description": "Get a list of all GPS devices for the currently authenticated user",
+                "description": "Get the device groups of the currently authenticated user",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "device-groups"
                 ],
-                "summary": "Get all GPS devices",
+                "summary": "Get device groups",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.GPSDevice"
+                                "$ref": "#/definitions/schemas.DeviceGroupResponse"
                             }
                         }
                     },
@@ -1209,7 +1209,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Create a new GPS device for the currently authenticated user",
+                "description": "Create a device group owned by the currently authenticated user, optionally with its first devices",
                 "consumes": [
                     "application/json"
                 ],
@@ -1217,17 +1217,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "device-groups"
                 ],
-                "summary": "Create a new GPS device",
+                "summary": "Create a device group",
                 "parameters": [
                     {
-                        "description": "Create GPS device",
-                        "name": "createGPSDevice",
+                        "description": "Create device group",
+                        "name": "createDeviceGroup",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/schemas.CreateGPSDevice"
+                            "$ref": "#/definitions/schemas.CreateDeviceGroup"
                         }
                     }
                 ],
@@ -1235,7 +1235,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.GPSDevice"
+                            "$ref": "#/definitions/schemas.DeviceGroupResponse"
                         }
                     },
                     "400": {
@@ -1244,6 +1244,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/schemas.Error"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1253,20 +1259,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/devices/{id}": {
+        "/api/device-groups/{id}": {
             "get": {
-                "description": "Get details of a GPS device by its ID, including its locations",
+                "description": "Get a device group with the IDs of its devices",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "device-groups"
                 ],
-                "summary": "Get a GPS device by ID",
+                "summary": "Get a device group by ID",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Device ID",
+                        "description": "Device group ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1276,7 +1282,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.GPSDevice"
+                            "$ref": "#/definitions/schemas.DeviceGroupResponse"
                         }
                     },
                     "404": {
@@ -1293,48 +1299,27 @@ const docTemplate = `{
                     }
                 }
             },
-            "patch": {
-                "description": "Update a GPS device for the currently authenticated user",
-                "consumes": [
-                    "application/json"
-                ],
+            "delete": {
+                "description": "Delete a device group, its devices are left untouched",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "device-groups"
                 ],
-                "summary": "Update a GPS device",
+                "summary": "Delete a device group",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Device ID",
+                        "description": "Device group ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Update GPS device",
-                        "name": "updateGPSDevice",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/schemas.UpdateGPSDevice"
-                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.GPSDevice"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/schemas.Error"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "404": {
                         "description": "Not Found",
@@ -1349,11 +1334,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/events": {
-            "post": {
-                "description": "Create a new event for the currently authenticated user",
+            },
+            "patch": {
+                "description": "Rename a device group or change its description",
                 "consumes": [
                     "application/json"
                 ],
@@ -1361,25 +1344,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "events"
+                    "device-groups"
                 ],
-                "summary": "Create a new event",
+                "summary": "Update a device group",
                 "parameters": [
                     {
-                        "description": "Create event",
-                        "name": "createEvent",
+                        "type": "string",
+                        "description": "Device group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update device group",
+                        "name": "updateDeviceGroup",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/schemas.CreateEvent"
+                            "$ref": "#/definitions/schemas.UpdateDeviceGroup"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Event"
+                            "$ref": "#/definitions/schemas.DeviceGroupResponse"
                         }
                     },
                     "400": {
@@ -1388,6 +1378,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/schemas.Error"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1397,24 +1393,34 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/events/from-area": {
+        "/api/device-groups/{id}/add-devices": {
             "post": {
-                "description": "Get events in an area by its ID",
+                "description": "Add devices the user can see to a device group, devices already in the group are ignored",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "events"
+                    "device-groups"
                 ],
-                "summary": "Get events in an area",
+                "summary": "Add devices to a device group",
                 "parameters": [
                     {
-                        "description": "Create area of interest",
-                        "name": "createAreaOfInterest",
+                        "type": "string",
+                        "description": "Device group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Devices to add",
+                        "name": "groupDevices",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/schemas.CreateAreaOfInterest"
+                            "$ref": "#/definitions/schemas.GroupDevices"
                         }
                     }
                 ],
@@ -1422,10 +1428,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Event"
-                            }
+                            "$ref": "#/definitions/schemas.DeviceGroupResponse"
                         }
                     },
                     "400": {
@@ -1434,6 +1437,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/schemas.Error"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1443,30 +1452,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/events/{id}": {
-            "get": {
-                "description": "Get details of an event by its ID",
+        "/api/device-groups/{id}/bulk": {
+            "post": {
+                "description": "Enable or disable tracking, send a command, make a community track the devices or change their poll interval. The action is applied device by device, devices the user may not change are reported as failed.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "events"
+                    "device-groups"
                 ],
-                "summary": "Get an event by ID",
+                "summary": "Apply an action to every device of a group",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Event ID",
+                        "description": "Device group ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Bulk device action",
+                        "name": "bulkDeviceAction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/schemas.BulkDeviceAction"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Event"
+                            "$ref": "#/definitions/schemas.BulkActionResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
                         }
                     },
                     "403": {
@@ -1488,33 +1515,35 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete an event by its ID",
+            }
+        },
+        "/api/device-groups/{id}/positions": {
+            "get": {
+                "description": "Get the latest accepted position of every device of a group visible to the user",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "events"
+                    "device-groups"
                 ],
-                "summary": "Delete an event",
+                "summary": "Get latest positions of a device group",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Event ID",
+                        "description": "Device group ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
-                    },
-                    "403": {
-                        "description": "Forbidden",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/schemas.Error"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/schemas.DevicePosition"
+                            }
                         }
                     },
                     "404": {
@@ -1530,9 +1559,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Update an event by its ID",
+            }
+        },
+        "/api/device-groups/{id}/remove-devices": {
+            "post": {
+                "description": "Remove devices from a device group, devices not in the group are ignored",
                 "consumes": [
                     "application/json"
                 ],
@@ -1540,24 +1571,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "events"
+                    "device-groups"
                 ],
-                "summary": "Update an event",
+                "summary": "Remove devices from a device group",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Event ID",
+                        "description": "Device group ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Update event",
-                        "name": "updateEvent",
+                        "description": "Devices to remove",
+                        "name": "groupDevices",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/schemas.UpdateEvent"
+                            "$ref": "#/definitions/schemas.GroupDevices"
                         }
                     }
                 ],
@@ -1565,7 +1596,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Event"
+                            "$ref": "#/definitions/schemas.DeviceGroupResponse"
                         }
                     },
                     "400": {
@@ -1574,12 +1605,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/schemas.Error"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "$ref": "#/definitions/schemas.Error"
-                        }
-                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -1595,20 +1620,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/events/{id}/comments": {
+        "/api/device-groups/{id}/status": {
             "get": {
-                "description": "Get all comments for an event by its ID",
+                "description": "Count the devices of a group by status and list the tracked ones needing attention",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "comments"
+                    "device-groups"
                 ],
-                "summary": "Get comments for an event",
+                "summary": "Get status of a device group",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Event ID",
+                        "description": "Device group ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1618,23 +1643,43 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Comment"
-                            }
+                            "$ref": "#/definitions/schemas.DeviceGroupStatus"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/schemas.Error"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/schemas.Error"
                         }
+                    }
+                }
+            }
+        },
+        "/api/devices": {
+            "get": {
+                "description": "Get the GPS devices the currently authenticated user owns or that are shared with them",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Get all GPS devices",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/schemas.GPSDeviceResponse"
+                            }
+                        }
                     },
                     "500": {
                         "description": "Internal Server Error",
@@ -1645,7 +1690,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Post a comment on an event by its ID",
+                "description": "Create a new GPS device for the currently authenticated user",
                 "consumes": [
                     "application/json"
                 ],
@@ -1653,24 +1698,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "comments"
+                    "devices"
                 ],
-                "summary": "Post a comment on an event",
+                "summary": "Create a new GPS device",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Event ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Create comment",
-                        "name": "createComment",
+                        "description": "Create GPS device",
+                        "name": "createGPSDevice",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/schemas.CreateComment"
+                            "$ref": "#/definitions/schemas.CreateGPSDevice"
                         }
                     }
                 ],
@@ -1678,7 +1716,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.Comment"
+                            "$ref": "#/definitions/schemas.GPSDeviceResponse"
                         }
                     },
                     "400": {
@@ -1687,16 +1725,33 @@ const docTemplate = `{
                             "$ref": "#/definitions/schemas.Error"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/schemas.Error"
                         }
-                    },
-                    "404": {
-                        "description": "Not Found",
+                    }
+                }
+            }
+        },
+        "/api/devices/attention": {
+            "get": {
+                "description": "Get tracked devices visible to the user that are offline, never reported, lost their GPS fix, run low on battery or keep failing to poll",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Get devices needing attention",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/schemas.Error"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/schemas.DeviceAttention"
+                            }
                         }
                     },
                     "500": {
@@ -1708,40 +1763,59 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/events/{id}/media": {
+        "/api/devices/import": {
             "post": {
-                "description": "Upload media to an event by its ID",
+                "description": "Create many devices from a CSV file with a header row named like the JSON fields, or from a JSON list. Every row is validated, IMEIs and phone numbers must be valid and unused. Valid rows are created together, invalid rows are reported with their errors. With dry_run nothing is created.",
                 "consumes": [
+                    "application/json",
                     "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "events"
+                    "devices"
                 ],
-                "summary": "Upload media to an event",
+                "summary": "Import GPS devices in bulk",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Event ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Devices to import",
+                        "name": "deviceImport",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.DeviceImport"
+                        }
                     },
                     {
                         "type": "file",
-                        "description": "File to upload",
+                        "description": "CSV file",
                         "name": "file",
-                        "in": "formData",
-                        "required": true
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only validate the rows",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Community tracking the created devices",
+                        "name": "community_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.DeviceImportResult"
+                        }
+                    },
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.MediaFile"
+                            "$ref": "#/definitions/schemas.DeviceImportResult"
                         }
                     },
                     "400": {
@@ -1771,50 +1845,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/me/community-invites": {
-            "get": {
-                "description": "Get all community invites for the currently authenticated user",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "community-invites"
-                ],
-                "summary": "Get community invites for user",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.CommunityInvite"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/schemas.Error"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/users/by-email/{email}": {
+        "/api/devices/{id}": {
             "get": {
-                "description": "Get a user by their email address",
+                "description": "Get details of a GPS device by its ID, including its latest locations",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "devices"
                 ],
-                "summary": "Get user by email",
+                "summary": "Get a GPS device by ID",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User Email",
-                        "name": "email",
+                        "description": "Device ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -1823,7 +1868,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/schemas.GPSDeviceResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
                         }
                     },
                     "500": {
@@ -1833,58 +1884,60 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/users/{id}": {
-            "get": {
-                "description": "Get a user by their ID",
+            },
+            "patch": {
+                "description": "Update a GPS device for the currently authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "devices"
                 ],
-                "summary": "Get user by ID",
+                "summary": "Update a GPS device",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
+                        "description": "Device ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Update GPS device",
+                        "name": "updateGPSDevice",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/schemas.UpdateGPSDevice"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/schemas.GPSDeviceResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/schemas.Error"
                         }
-                    }
-                }
-            }
-        },
-        "/me": {
-            "get": {
-                "description": "Get the profile of the currently authenticated user",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "me"
-                ],
-                "summary": "Get current user profile",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/schemas.UserProfile"
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
                         }
                     },
                     "500": {
@@ -1894,35 +1947,71 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Update the profile of the currently authenticated user",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/api/devices/{id}/commands": {
+            "get": {
+                "description": "Get every command queued for a device with its delivery status, latest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "me"
+                    "devices"
                 ],
-                "summary": "Update current user profile",
+                "summary": "Get commands sent to a GPS device",
                 "parameters": [
                     {
-                        "description": "Update user profile",
-                        "name": "updateMe",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/schemas.UpdateMe"
-                        }
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/schemas.UserProfile"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/schemas.Paginated"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "items": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.DeviceCommand"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -1931,33 +2020,16 @@ const docTemplate = `{
                             "$ref": "#/definitions/schemas.Error"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/schemas.Error"
                         }
-                    }
-                }
-            }
-        },
-        "/me/areas-of-interest": {
-            "get": {
-                "description": "Get all areas of interest for the currently authenticated user",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "me"
-                ],
-                "summary": "Get areas of interest",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.AreaOfInterest"
-                            }
+                            "$ref": "#/definitions/schemas.Error"
                         }
                     },
                     "500": {
@@ -1969,7 +2041,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Create a new area of interest for the currently authenticated user",
+                "description": "Queue a command for a device. It is sent over SMS by the worker and retried until the device acknowledges it or attempts are exhausted.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1977,25 +2049,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "me"
+                    "devices"
                 ],
-                "summary": "Create a new area of interest",
+                "summary": "Send a command to a GPS device",
                 "parameters": [
                     {
-                        "description": "Create area of interest",
-                        "name": "createAreaOfInterest",
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create device command",
+                        "name": "createDeviceCommand",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/schemas.CreateAreaOfInterest"
+                            "$ref": "#/definitions/schemas.CreateDeviceCommand"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "$ref": "#/definitions/models.AreaOfInterest"
+                            "$ref": "#/definitions/models.DeviceCommand"
                         }
                     },
                     "400": {
@@ -2004,71 +2083,146 @@ const docTemplate = `{
                             "$ref": "#/definitions/schemas.Error"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/schemas.Error"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
                     }
                 }
             }
         },
-        "/me/areas-of-interest/{area_of_interest_id}": {
-            "delete": {
-                "description": "Delete an area of interest for the currently authenticated user",
+        "/api/devices/{id}/commands/catalog": {
+            "get": {
+                "description": "Get the commands the model of a device understands",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "me"
+                    "devices"
                 ],
-                "summary": "Delete an area of interest",
+                "summary": "Get commands supported by a GPS device",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Area of interest ID",
-                        "name": "area_of_interest_id",
+                        "description": "Device ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/commands.Definition"
+                            }
+                        }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/schemas.Error"
                         }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "$ref": "#/definitions/schemas.Error"
-                        }
                     }
                 }
             }
         },
-        "/me/communities": {
+        "/api/devices/{id}/geofence-transitions": {
             "get": {
-                "description": "Get all communities the currently authenticated user is a member of",
+                "description": "Get the enter, exit and dwell transitions recorded for a device, latest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "me"
+                    "devices"
+                ],
+                "summary": "Get geofence transitions of a GPS device",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "page_size",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get user communities",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Community"
-                            }
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/schemas.Paginated"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "items": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.GeofenceTransition"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
                         }
                     },
                     "500": {
@@ -2080,39 +2234,37 @@ const docTemplate = `{
                 }
             }
         },
-        "/me/feed": {
+        "/api/devices/{id}/geofences": {
             "get": {
-                "description": "Get the feed of events for the currently authenticated user",
+                "description": "Get the areas of interest watched for a device together with their enter, exit or dwell triggers",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "me"
+                    "devices"
                 ],
-                "summary": "Get user feed",
+                "summary": "Get geofence rules of a GPS device",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Number of items per page",
-                        "name": "page_size",
-                        "in": "query"
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/schemas.Paginated"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.GeofenceRule"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/schemas.Error"
                         }
@@ -2124,11 +2276,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/me/track-device": {
+            },
             "post": {
-                "description": "Track a GPS device for the currently authenticated user",
+                "description": "Watch an existing area of interest, or a new one, for a device. Everyone tracking the device is notified when the rule triggers.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2136,25 +2286,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "me"
+                    "devices"
                 ],
-                "summary": "Track a device",
+                "summary": "Create a geofence rule for a GPS device",
                 "parameters": [
                     {
-                        "description": "Track device",
-                        "name": "trackDevice",
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create geofence rule",
+                        "name": "createGeofenceRule",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/schemas.TrackDevice"
+                            "$ref": "#/definitions/schemas.CreateGeofenceRule"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/schemas.UserProfile"
+                            "$ref": "#/definitions/models.GeofenceRule"
                         }
                     },
                     "400": {
@@ -2163,6 +2320,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/schemas.Error"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -2178,35 +2341,183 @@ const docTemplate = `{
                 }
             }
         },
-        "/me/untrack-device": {
+        "/api/devices/{id}/geofences/{geofence_id}": {
+            "delete": {
+                "description": "Stop watching an area of interest for a device. The area of interest itself is kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Delete a geofence rule of a GPS device",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Geofence rule ID",
+                        "name": "geofence_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/devices/{id}/import": {
             "post": {
-                "description": "Untrack a GPS device for the currently authenticated user",
+                "description": "Import historical locations from a GPX, KML or GeoJSON file. Every point needs a timestamp, a file with untimed points is rejected as a whole. Points already present in the device history are skipped.\nPlain KML LineStrings carry no time per point, their points are spread evenly over the TimeSpan of the placemark, or all pinned to its TimeStamp, so the stored fix times are estimates.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "me"
+                    "devices"
                 ],
-                "summary": "Untrack a device",
+                "summary": "Import a track into a GPS device",
                 "parameters": [
                     {
-                        "description": "Untrack device",
-                        "name": "trackDevice",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "GPX, KML or GeoJSON file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/schemas.TrackDevice"
+                            "$ref": "#/definitions/schemas.TrackImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/devices/{id}/locations": {
+            "get": {
+                "description": "Get locations of a device in a time range. JSON responses are paginated with a cursor, other formats stream the whole range as a file.",
+                "produces": [
+                    "application/json",
+                    "application/geo+json",
+                    "application/gpx+xml",
+                    "application/vnd.google-earth.kml+xml"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Get location history of a GPS device",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of locations per page, JSON only",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json, geojson, geojson_line, gpx or kml",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include points rejected by the ingestion filters",
+                        "name": "include_filtered",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/schemas.UserProfile"
+                            "$ref": "#/definitions/schemas.LocationPage"
                         }
                     },
                     "400": {
@@ -2229,22 +2540,2948 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "gorm.DeletedAt": {
-            "type": "object",
+        },
+        "/api/devices/{id}/shares": {
+            "get": {
+                "description": "Get the users and communities a device is shared with, including expired shares",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Get shares of a GPS device",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DeviceShare"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Share a device with a user or with every member of a community, as viewer or manager and optionally until a given time. Only the owner can share a device.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Share a GPS device",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create device share",
+                        "name": "createDeviceShare",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/schemas.CreateDeviceShare"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.DeviceShare"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/devices/{id}/shares/{share_id}": {
+            "delete": {
+                "description": "Revoke a share immediately. Only the owner can revoke shares.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Revoke a share of a GPS device",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Share ID",
+                        "name": "share_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Change the role or the expiry of a share. Only the owner can change shares.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Update a share of a GPS device",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Share ID",
+                        "name": "share_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update device share",
+                        "name": "updateDeviceShare",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/schemas.UpdateDeviceShare"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DeviceShare"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/devices/{id}/stats": {
+            "get": {
+                "description": "Get the distance travelled, moving time and idle time of a device per day, week or month, with its odometer. Days follow the timezone of the device and are rolled up periodically, so the latest movements may be missing.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Get distance and time statistics of a GPS device",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "First day of the range (YYYY-MM-DD), 30 days before to by default",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day of the range (YYYY-MM-DD), today by default",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "day, week or month",
+                        "name": "granularity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json or csv",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.DeviceStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/devices/{id}/stops": {
+            "get": {
+                "description": "Get stops detected in the location history of a device, latest first. A stop is reported once the device left it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Get stops of a GPS device",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/schemas.Paginated"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "items": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Stop"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/devices/{id}/trips": {
+            "get": {
+                "description": "Get trips detected in the location history of a device, latest first. Trips are detected periodically and only once the device stopped.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Get trips of a GPS device",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/schemas.Paginated"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "items": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Trip"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/events": {
+            "post": {
+                "description": "Create a new event for the currently authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Create a new event",
+                "parameters": [
+                    {
+                        "description": "Create event",
+                        "name": "createEvent",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/schemas.CreateEvent"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/events/from-area": {
+            "post": {
+                "description": "Get events in an area by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Get events in an area",
+                "parameters": [
+                    {
+                        "description": "Create area of interest",
+                        "name": "createAreaOfInterest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/schemas.CreateAreaOfInterest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Event"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/events/{id}": {
+            "get": {
+                "description": "Get details of an event by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Get an event by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Event"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete an event by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Delete an event",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update an event by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Update an event",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update event",
+                        "name": "updateEvent",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/schemas.UpdateEvent"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/events/{id}/comments": {
+            "get": {
+                "description": "Get all comments for an event by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Get comments for an event",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Comment"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Post a comment on an event by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Post a comment on an event",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create comment",
+                        "name": "createComment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/schemas.CreateComment"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Comment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/events/{id}/media": {
+            "post": {
+                "description": "Upload media to an event by its ID",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Upload media to an event",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Event ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "File to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.MediaFile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/me/community-invites": {
+            "get": {
+                "description": "Get all community invites for the currently authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "community-invites"
+                ],
+                "summary": "Get community invites for user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CommunityInvite"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/provider-accounts": {
+            "get": {
+                "description": "Get the tracker vendor accounts of the currently authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "provider-accounts"
+                ],
+                "summary": "Get provider accounts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ProviderAccount"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Store the login of a tracker vendor account owning several trackers. Devices linked to it are polled with one session, matched by IMEI.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "provider-accounts"
+                ],
+                "summary": "Create a provider account",
+                "parameters": [
+                    {
+                        "description": "Create provider account",
+                        "name": "createProviderAccount",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/schemas.CreateProviderAccount"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProviderAccount"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/provider-accounts/{id}": {
+            "delete": {
+                "description": "Delete a tracker vendor account. Its devices are kept but no longer polled until linked again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "provider-accounts"
+                ],
+                "summary": "Delete a provider account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider account ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Change the password of a tracker vendor account, the next poll logs in again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "provider-accounts"
+                ],
+                "summary": "Update a provider account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider account ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update provider account",
+                        "name": "updateProviderAccount",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/schemas.UpdateProviderAccount"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProviderAccount"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stream": {
+            "get": {
+                "description": "Stream new locations, device status changes, events and comments. Without filters the stream follows every device visible to the user and every community of the user. Browsers unable to set the Authorization header pass a ticket from POST /api/stream/ticket instead.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "stream"
+                ],
+                "summary": "Stream changes as Server-Sent Events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated IDs of devices visible to the user",
+                        "name": "devices",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated IDs of communities of the user",
+                        "name": "communities",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Area as min_lon,min_lat,max_lon,max_lat",
+                        "name": "bbox",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Single use ticket, for clients unable to set the Authorization header",
+                        "name": "ticket",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/realtime.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stream/ticket": {
+            "post": {
+                "description": "Create a single use ticket opening a stream within 30 seconds, for clients unable to set the Authorization header on stream requests. Access tokens must never be put in URLs.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stream"
+                ],
+                "summary": "Create a stream ticket",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.StreamTicket"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/stream/ws": {
+            "get": {
+                "description": "Same stream as /api/stream, every message is a JSON text frame. Browsers unable to set the Authorization header pass a ticket from POST /api/stream/ticket instead.",
+                "tags": [
+                    "stream"
+                ],
+                "summary": "Stream changes over a WebSocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated IDs of devices visible to the user",
+                        "name": "devices",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated IDs of communities of the user",
+                        "name": "communities",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Area as min_lon,min_lat,max_lon,max_lat",
+                        "name": "bbox",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Single use ticket, for clients unable to set the Authorization header",
+                        "name": "ticket",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/realtime.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/by-email/{email}": {
+            "get": {
+                "description": "Get a user by their email address",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user by email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User Email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{id}": {
+            "get": {
+                "description": "Get a user by their ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/ingest/osmand": {
+            "get": {
+                "description": "Store a single location sent by the OsmAnd or Traccar Client. The id parameter is the ingest token of the device.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingest"
+                ],
+                "summary": "Push a location in OsmAnd format",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Device ingest token",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude",
+                        "name": "lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude",
+                        "name": "lon",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unix seconds, unix milliseconds or ISO 8601 time",
+                        "name": "timestamp",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Speed",
+                        "name": "speed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Bearing",
+                        "name": "bearing",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Altitude",
+                        "name": "altitude",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Accuracy in meters",
+                        "name": "accuracy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Battery level",
+                        "name": "batt",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Store a single location sent by the OsmAnd or Traccar Client. The id parameter is the ingest token of the device.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingest"
+                ],
+                "summary": "Push a location in OsmAnd format",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Device ingest token",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude",
+                        "name": "lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude",
+                        "name": "lon",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unix seconds, unix milliseconds or ISO 8601 time",
+                        "name": "timestamp",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Speed",
+                        "name": "speed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Bearing",
+                        "name": "bearing",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Altitude",
+                        "name": "altitude",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Accuracy in meters",
+                        "name": "accuracy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Battery level",
+                        "name": "batt",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/ingest/osmand/batch": {
+            "post": {
+                "description": "Store a batch of locations recorded while the phone was offline. Invalid points reject the whole batch.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ingest"
+                ],
+                "summary": "Push buffered locations in OsmAnd format",
+                "parameters": [
+                    {
+                        "description": "Buffered locations",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/schemas.OsmAndBatch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/me": {
+            "get": {
+                "description": "Get the profile of the currently authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Get current user profile",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.UserProfile"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update the profile of the currently authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Update current user profile",
+                "parameters": [
+                    {
+                        "description": "Update user profile",
+                        "name": "updateMe",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/schemas.UpdateMe"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.UserProfile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/areas-of-interest": {
+            "get": {
+                "description": "Get all areas of interest for the currently authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Get areas of interest",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AreaOfInterest"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new area of interest for the currently authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Create a new area of interest",
+                "parameters": [
+                    {
+                        "description": "Create area of interest",
+                        "name": "createAreaOfInterest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/schemas.CreateAreaOfInterest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.AreaOfInterest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/areas-of-interest/{area_of_interest_id}": {
+            "delete": {
+                "description": "Delete an area of interest for the currently authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Delete an area of interest",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Area of interest ID",
+                        "name": "area_of_interest_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/communities": {
+            "get": {
+                "description": "Get all communities the currently authenticated user is a member of",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Get user communities",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Community"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/feed": {
+            "get": {
+                "description": "Get the feed of events for the currently authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Get user feed",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items per page",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Paginated"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/track-device": {
+            "post": {
+                "description": "Track a GPS device for the currently authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Track a device",
+                "parameters": [
+                    {
+                        "description": "Track device",
+                        "name": "trackDevice",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/schemas.TrackDevice"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.UserProfile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/untrack-device": {
+            "post": {
+                "description": "Untrack a GPS device for the currently authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "me"
+                ],
+                "summary": "Untrack a device",
+                "parameters": [
+                    {
+                        "description": "Untrack device",
+                        "name": "trackDevice",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/schemas.TrackDevice"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.UserProfile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/twilio/sms": {
+            "post": {
+                "description": "Twilio webhook for inbound SMS. Replies of trackers with a known number are parsed for a position, which is stored as a location of the device.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Receive an SMS from a tracker",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Twilio request signature",
+                        "name": "X-Twilio-Signature",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sender phone number",
+                        "name": "From",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Message text",
+                        "name": "Body",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/twilio/status": {
+            "post": {
+                "description": "Twilio status callback for outbound SMS. Delivery reports update the device command sent with the message.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Receive the delivery status of an SMS",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Twilio request signature",
+                        "name": "X-Twilio-Signature",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Message SID",
+                        "name": "MessageSid",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Message status",
+                        "name": "MessageStatus",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Twilio error code",
+                        "name": "ErrorCode",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/schemas.Error"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "commands.Definition": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "retryable": {
+                    "description": "Retryable commands are sent again when the device doesn't answer. Running them twice is harmless,\nunlike a reset or a restart the device may have carried out with only its reply lost.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "gorm.DeletedAt": {
+            "type": "object",
+            "properties": {
+                "time": {
+                    "type": "string"
+                },
+                "valid": {
+                    "description": "Valid is true if Time is not NULL",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.AreaOfInterest": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Event"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "polygon_area": {
+                    "type": "string"
+                },
+                "radius_in_meters": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Comment": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "$ref": "#/definitions/models.User"
+                },
+                "created_by_id": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "event_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Community": {
+            "type": "object",
+            "properties": {
+                "allow_read_only_members_add_events": {
+                    "type": "boolean"
+                },
+                "appears_in_search": {
+                    "type": "boolean"
+                },
+                "areas_of_interest": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AreaOfInterest"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Event"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "include_external_events": {
+                    "type": "boolean"
+                },
+                "location_retention_days": {
+                    "type": "integer"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CommunityMember"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "tracking_devices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GPSDevice"
+                    }
+                },
+                "type": {
+                    "$ref": "#/definitions/models.CommunityType"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommunityInvite": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "boolean"
+                },
+                "community_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "creator_id": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/models.MemberRole"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CommunityMember": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "$ref": "#/definitions/models.MemberRole"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                }
+            }
+        },
+        "models.CommunityType": {
+            "type": "string",
+            "enum": [
+                "public",
+                "private"
+            ],
+            "x-enum-varnames": [
+                "PUBLIC",
+                "PRIVATE"
+            ]
+        },
+        "models.DeviceCommand": {
+            "type": "object",
+            "properties": {
+                "acknowledged_at": {
+                    "type": "string"
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by_id": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "message_sid": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "response": {
+                    "type": "string"
+                },
+                "retryable": {
+                    "type": "boolean"
+                },
+                "sent_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.DeviceCommandStatus"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DeviceCommandStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "sending",
+                "sent",
+                "delivered",
+                "acknowledged",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "CommandPending",
+                "CommandSending",
+                "CommandSent",
+                "CommandDelivered",
+                "CommandAcknowledged",
+                "CommandFailed"
+            ]
+        },
+        "models.DeviceProviderType": {
+            "type": "string",
+            "enum": [
+                "365gps",
+                "gt06",
+                "osmand",
+                "simulator"
+            ],
+            "x-enum-varnames": [
+                "Provider365GPS",
+                "ProviderGT06",
+                "ProviderOsmAnd",
+                "ProviderSimulator"
+            ]
+        },
+        "models.DeviceRole": {
+            "type": "string",
+            "enum": [
+                "viewer",
+                "manager",
+                "owner"
+            ],
+            "x-enum-varnames": [
+                "DeviceRoleViewer",
+                "DeviceRoleManager",
+                "DeviceRoleOwner"
+            ]
+        },
+        "models.DeviceShare": {
+            "type": "object",
+            "properties": {
+                "community_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by_id": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/models.DeviceRole"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DeviceStatus": {
+            "type": "string",
+            "enum": [
+                "unknown",
+                "online",
+                "offline"
+            ],
+            "x-enum-varnames": [
+                "DeviceStatusUnknown",
+                "DeviceStatusOnline",
+                "DeviceStatusOffline"
+            ]
+        },
+        "models.Event": {
+            "type": "object",
+            "properties": {
+                "comments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Comment"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by_id": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "device": {
+                    "$ref": "#/definitions/models.GPSDevice"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_public": {
+                    "type": "boolean"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "media_presigned_urls": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PresignedUrl"
+                    }
+                },
+                "status": {
+                    "$ref": "#/definitions/models.EventStatus"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/models.EventType"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.EventStatus": {
+            "type": "string",
+            "enum": [
+                "open",
+                "resolved",
+                "closed"
+            ],
+            "x-enum-varnames": [
+                "EventStatusOpen",
+                "EventStatusResolved",
+                "EventStatusClosed"
+            ]
+        },
+        "models.EventType": {
+            "type": "string",
+            "enum": [
+                "robbery",
+                "lost",
+                "accident",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "EventTypeRobbery",
+                "EventTypeLost",
+                "EventTypeAccident",
+                "EventTypeOther"
+            ]
+        },
+        "models.GPSDevice": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string"
+                },
+                "adaptive_polling": {
+                    "description": "AdaptivePolling overrides whether the interval follows the movement of the device, like PollIntervalSeconds",
+                    "type": "boolean"
+                },
+                "battery": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "imei": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_error_at": {
+                    "type": "string"
+                },
+                "last_fix_at": {
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "locations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GPSLocation"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "poll_failures": {
+                    "type": "integer"
+                },
+                "poll_interval_seconds": {
+                    "description": "PollIntervalSeconds overrides the poll interval of the groups of the device and of the config",
+                    "type": "integer"
+                },
+                "provider": {
+                    "$ref": "#/definitions/models.DeviceProviderType"
+                },
+                "retention_days": {
+                    "type": "integer"
+                },
+                "signal": {
+                    "type": "number"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.DeviceStatus"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "tracking": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GPSLocation": {
+            "type": "object",
+            "properties": {
+                "accuracy": {
+                    "type": "number"
+                },
+                "altitude": {
+                    "type": "number"
+                },
+                "battery": {
+                    "type": "number"
+                },
+                "course": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "filter_reason": {
+                    "description": "FilterReason is set on points rejected on ingestion, they are kept for auditing but left out of tracks, trips and alerts",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.LocationFilterReason"
+                        }
+                    ]
+                },
+                "fix_time": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "raw_latitude": {
+                    "description": "RawLatitude and RawLongitude keep the reported position of points moved by smoothing",
+                    "type": "number"
+                },
+                "raw_longitude": {
+                    "type": "number"
+                },
+                "satellites": {
+                    "type": "integer"
+                },
+                "signal": {
+                    "type": "number"
+                },
+                "speed": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GeofenceRule": {
+            "type": "object",
+            "properties": {
+                "area_of_interest": {
+                    "$ref": "#/definitions/models.AreaOfInterest"
+                },
+                "area_of_interest_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by_id": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "dwell_minutes": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "trigger": {
+                    "$ref": "#/definitions/models.GeofenceTrigger"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GeofenceTransition": {
+            "type": "object",
+            "properties": {
+                "area_of_interest_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "fix_time": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "location_id": {
+                    "type": "string"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "rule_id": {
+                    "type": "string"
+                },
+                "trigger": {
+                    "$ref": "#/definitions/models.GeofenceTrigger"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GeofenceTrigger": {
+            "type": "string",
+            "enum": [
+                "enter",
+                "exit",
+                "dwell"
+            ],
+            "x-enum-varnames": [
+                "GeofenceEnter",
+                "GeofenceExit",
+                "GeofenceDwell"
+            ]
+        },
+        "models.LocationFilterReason": {
+            "type": "string",
+            "enum": [
+                "null_island",
+                "duplicate",
+                "low_accuracy",
+                "jump"
+            ],
+            "x-enum-varnames": [
+                "LocationFilterNullIsland",
+                "LocationFilterDuplicate",
+                "LocationFilterLowAccuracy",
+                "LocationFilterJump"
+            ]
+        },
+        "models.MediaFile": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MemberRole": {
+            "type": "string",
+            "enum": [
+                "admin",
+                "read_only"
+            ],
+            "x-enum-varnames": [
+                "ADMIN",
+                "READ_ONLY"
+            ]
+        },
+        "models.PresignedUrl": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ProviderAccount": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by_id": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "provider": {
+                    "$ref": "#/definitions/models.DeviceProviderType"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Stop": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "duration_seconds": {
+                    "type": "integer"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Trip": {
+            "type": "object",
+            "properties": {
+                "avg_speed": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "distance_meters": {
+                    "type": "number"
+                },
+                "duration_seconds": {
+                    "type": "integer"
+                },
+                "end_latitude": {
+                    "type": "number"
+                },
+                "end_longitude": {
+                    "type": "number"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_speed": {
+                    "type": "number"
+                },
+                "start_latitude": {
+                    "type": "number"
+                },
+                "start_longitude": {
+                    "type": "number"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
             "properties": {
-                "time": {
+                "areas_of_interest": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AreaOfInterest"
+                    }
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "valid": {
-                    "description": "Valid is true if Time is not NULL",
+                "deleted_at": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "email_verified": {
                     "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "models.AreaOfInterest": {
+        "models.UserSettings": {
             "type": "object",
             "properties": {
                 "created_at": {
@@ -2253,13 +5490,33 @@ const docTemplate = `{
                 "deleted_at": {
                     "$ref": "#/definitions/gorm.DeletedAt"
                 },
-                "events": {
+                "id": {
+                    "type": "string"
+                },
+                "tracking_devices": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Event"
+                        "$ref": "#/definitions/models.GPSDevice"
                     }
                 },
-                "id": {
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "realtime.Message": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "device_id": {
+                    "type": "string"
+                },
+                "event_id": {
                     "type": "string"
                 },
                 "latitude": {
@@ -2268,48 +5525,131 @@ const docTemplate = `{
                 "longitude": {
                     "type": "number"
                 },
-                "polygon_area": {
+                "type": {
+                    "$ref": "#/definitions/realtime.MessageType"
+                }
+            }
+        },
+        "realtime.MessageType": {
+            "type": "string",
+            "enum": [
+                "location",
+                "device_status",
+                "event",
+                "comment"
+            ],
+            "x-enum-varnames": [
+                "MessageLocation",
+                "MessageDeviceStatus",
+                "MessageEvent",
+                "MessageComment"
+            ]
+        },
+        "schemas.AddEvent": {
+            "type": "object",
+            "required": [
+                "event_id"
+            ],
+            "properties": {
+                "event_id": {
                     "type": "string"
-                },
-                "radius_in_meters": {
-                    "type": "number"
-                },
-                "updated_at": {
+                }
+            }
+        },
+        "schemas.AddMember": {
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "user_id": {
                     "type": "string"
                 }
             }
         },
-        "models.Comment": {
+        "schemas.BulkActionFailure": {
             "type": "object",
             "properties": {
-                "content": {
+                "device_id": {
                     "type": "string"
                 },
-                "created_at": {
+                "error": {
                     "type": "string"
+                }
+            }
+        },
+        "schemas.BulkActionResult": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/schemas.BulkActionFailure"
+                    }
                 },
-                "created_by": {
-                    "$ref": "#/definitions/models.User"
+                "succeeded": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "schemas.BulkDeviceAction": {
+            "type": "object",
+            "properties": {
+                "adaptive_polling": {
+                    "type": "boolean"
                 },
-                "created_by_id": {
+                "command": {
+                    "description": "Command is the name of a command from the catalog of each device",
                     "type": "string"
                 },
-                "deleted_at": {
-                    "$ref": "#/definitions/gorm.DeletedAt"
-                },
-                "event_id": {
+                "community_id": {
+                    "description": "CommunityID makes the community track the devices, the user must be one of its admins",
                     "type": "string"
                 },
-                "id": {
+                "poll_interval_seconds": {
+                    "type": "integer"
+                },
+                "tracking": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "schemas.CreateAreaOfInterest": {
+            "type": "object",
+            "properties": {
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "polygon_area": {
                     "type": "string"
                 },
-                "updated_at": {
+                "radius_in_meters": {
+                    "type": "number"
+                }
+            }
+        },
+        "schemas.CreateComment": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "content": {
                     "type": "string"
                 }
             }
         },
-        "models.Community": {
+        "schemas.CreateCommunity": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
                 "allow_read_only_members_add_events": {
                     "type": "boolean"
@@ -2317,38 +5657,14 @@ const docTemplate = `{
                 "appears_in_search": {
                     "type": "boolean"
                 },
-                "areas_of_interest": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.AreaOfInterest"
-                    }
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "deleted_at": {
-                    "$ref": "#/definitions/gorm.DeletedAt"
-                },
                 "description": {
                     "type": "string"
                 },
-                "events": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Event"
-                    }
-                },
-                "id": {
-                    "type": "string"
-                },
                 "include_external_events": {
                     "type": "boolean"
                 },
-                "members": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.CommunityMember"
-                    }
+                "location_retention_days": {
+                    "type": "integer"
                 },
                 "name": {
                     "type": "string"
@@ -2356,42 +5672,81 @@ const docTemplate = `{
                 "tracking_devices": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.GPSDevice"
+                        "type": "string"
                     }
                 },
                 "type": {
                     "$ref": "#/definitions/models.CommunityType"
+                }
+            }
+        },
+        "schemas.CreateCommunityInvite": {
+            "type": "object",
+            "required": [
+                "community_id",
+                "user_id"
+            ],
+            "properties": {
+                "community_id": {
+                    "type": "string"
                 },
-                "updated_at": {
+                "role": {
+                    "$ref": "#/definitions/models.MemberRole"
+                },
+                "user_id": {
                     "type": "string"
                 }
             }
         },
-        "models.CommunityInvite": {
+        "schemas.CreateDeviceCommand": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
-                "accepted": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "schemas.CreateDeviceGroup": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "adaptive_polling": {
                     "type": "boolean"
                 },
-                "community_id": {
+                "description": {
                     "type": "string"
                 },
-                "created_at": {
-                    "type": "string"
+                "device_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "creator_id": {
+                "name": {
                     "type": "string"
                 },
-                "deleted_at": {
-                    "$ref": "#/definitions/gorm.DeletedAt"
+                "poll_interval_seconds": {
+                    "description": "PollIntervalSeconds and AdaptivePolling apply to the members without their own setting",
+                    "type": "integer"
+                }
+            }
+        },
+        "schemas.CreateDeviceShare": {
+            "type": "object",
+            "properties": {
+                "community_id": {
+                    "type": "string"
                 },
-                "id": {
+                "expires_at": {
+                    "description": "ExpiresAt ends the share, it never expires when omitted",
                     "type": "string"
                 },
                 "role": {
-                    "$ref": "#/definitions/models.MemberRole"
-                },
-                "updated_at": {
                     "type": "string"
                 },
                 "user_id": {
@@ -2399,58 +5754,25 @@ const docTemplate = `{
                 }
             }
         },
-        "models.CommunityMember": {
+        "schemas.CreateEvent": {
             "type": "object",
-            "properties": {
-                "role": {
-                    "$ref": "#/definitions/models.MemberRole"
-                },
-                "user": {
-                    "$ref": "#/definitions/models.User"
-                }
-            }
-        },
-        "models.CommunityType": {
-            "type": "string",
-            "enum": [
-                "public",
-                "private"
+            "required": [
+                "description",
+                "title"
             ],
-            "x-enum-varnames": [
-                "PUBLIC",
-                "PRIVATE"
-            ]
-        },
-        "models.Event": {
-            "type": "object",
             "properties": {
-                "comments": {
+                "communities": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Comment"
+                        "type": "string"
                     }
                 },
-                "created_at": {
-                    "type": "string"
-                },
-                "created_by_id": {
-                    "type": "string"
-                },
-                "deleted_at": {
-                    "$ref": "#/definitions/gorm.DeletedAt"
-                },
                 "description": {
                     "type": "string"
                 },
-                "device": {
-                    "$ref": "#/definitions/models.GPSDevice"
-                },
                 "device_id": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "string"
-                },
                 "is_public": {
                     "type": "boolean"
                 },
@@ -2460,12 +5782,6 @@ const docTemplate = `{
                 "longitude": {
                     "type": "number"
                 },
-                "media_presigned_urls": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.PresignedUrl"
-                    }
-                },
                 "status": {
                     "$ref": "#/definitions/models.EventStatus"
                 },
@@ -2474,70 +5790,25 @@ const docTemplate = `{
                 },
                 "type": {
                     "$ref": "#/definitions/models.EventType"
-                },
-                "updated_at": {
-                    "type": "string"
                 }
             }
         },
-        "models.EventStatus": {
-            "type": "string",
-            "enum": [
-                "open",
-                "resolved",
-                "closed"
-            ],
-            "x-enum-varnames": [
-                "EventStatusOpen",
-                "EventStatusResolved",
-                "EventStatusClosed"
-            ]
-        },
-        "models.EventType": {
-            "type": "string",
-            "enum": [
-                "robbery",
-                "lost",
-                "accident",
-                "other"
-            ],
-            "x-enum-varnames": [
-                "EventTypeRobbery",
-                "EventTypeLost",
-                "EventTypeAccident",
-                "EventTypeOther"
-            ]
-        },
-        "models.GPSDevice": {
+        "schemas.CreateGPSDevice": {
             "type": "object",
             "properties": {
-                "api_cookie": {
-                    "type": "string"
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "created_by": {
+                "account_id": {
+                    "description": "AccountID polls the device through a shared provider account instead of its own IMEI and password",
                     "type": "string"
                 },
-                "deleted_at": {
-                    "$ref": "#/definitions/gorm.DeletedAt"
+                "adaptive_polling": {
+                    "type": "boolean"
                 },
                 "description": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "string"
-                },
                 "imei": {
                     "type": "string"
                 },
-                "locations": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.GPSLocation"
-                    }
-                },
                 "name": {
                     "type": "string"
                 },
@@ -2547,303 +5818,445 @@ const docTemplate = `{
                 "password": {
                     "type": "string"
                 },
-                "tracking": {
-                    "type": "boolean"
+                "poll_interval_seconds": {
+                    "type": "integer"
                 },
-                "updated_at": {
+                "provider": {
+                    "$ref": "#/definitions/models.DeviceProviderType"
+                },
+                "retention_days": {
+                    "type": "integer"
+                },
+                "timezone": {
                     "type": "string"
+                },
+                "tracking": {
+                    "type": "boolean"
                 }
             }
         },
-        "models.GPSLocation": {
+        "schemas.CreateGeofenceRule": {
             "type": "object",
+            "required": [
+                "trigger"
+            ],
             "properties": {
-                "created_at": {
+                "area_of_interest": {
+                    "$ref": "#/definitions/schemas.CreateAreaOfInterest"
+                },
+                "area_of_interest_id": {
                     "type": "string"
                 },
-                "deleted_at": {
-                    "$ref": "#/definitions/gorm.DeletedAt"
+                "dwell_minutes": {
+                    "type": "integer"
                 },
-                "device_id": {
+                "name": {
                     "type": "string"
                 },
-                "id": {
+                "trigger": {
+                    "type": "string"
+                }
+            }
+        },
+        "schemas.CreateProviderAccount": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
                     "type": "string"
                 },
-                "latitude": {
-                    "type": "number"
-                },
-                "longitude": {
-                    "type": "number"
+                "provider": {
+                    "$ref": "#/definitions/models.DeviceProviderType"
                 },
-                "updated_at": {
+                "username": {
                     "type": "string"
                 }
             }
         },
-        "models.MediaFile": {
+        "schemas.DeviceAttention": {
+            "type": "object",
+            "properties": {
+                "device": {
+                    "$ref": "#/definitions/schemas.GPSDeviceResponse"
+                },
+                "reasons": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "schemas.DeviceGroupResponse": {
             "type": "object",
             "properties": {
+                "adaptive_polling": {
+                    "type": "boolean"
+                },
                 "created_at": {
                     "type": "string"
                 },
-                "deleted_at": {
-                    "$ref": "#/definitions/gorm.DeletedAt"
+                "description": {
+                    "type": "string"
+                },
+                "device_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
                 "id": {
                     "type": "string"
                 },
-                "key": {
+                "name": {
+                    "type": "string"
+                },
+                "owner_id": {
                     "type": "string"
                 },
+                "poll_interval_seconds": {
+                    "type": "integer"
+                },
                 "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "models.MemberRole": {
-            "type": "string",
-            "enum": [
-                "admin",
-                "read_only"
-            ],
-            "x-enum-varnames": [
-                "ADMIN",
-                "READ_ONLY"
-            ]
-        },
-        "models.PresignedUrl": {
+        "schemas.DeviceGroupStatus": {
             "type": "object",
             "properties": {
-                "key": {
-                    "type": "string"
+                "attention": {
+                    "description": "Attention lists the tracked members needing attention, with the same reasons as the attention endpoint",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/schemas.DeviceAttention"
+                    }
                 },
-                "url": {
-                    "type": "string"
+                "offline": {
+                    "type": "integer"
+                },
+                "online": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "tracking": {
+                    "type": "integer"
+                },
+                "unknown": {
+                    "type": "integer"
                 }
             }
         },
-        "models.User": {
+        "schemas.DeviceImport": {
             "type": "object",
+            "required": [
+                "devices"
+            ],
             "properties": {
-                "areas_of_interest": {
+                "devices": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.AreaOfInterest"
+                        "$ref": "#/definitions/schemas.CreateGPSDevice"
                     }
+                }
+            }
+        },
+        "schemas.DeviceImportResult": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
                 },
-                "created_at": {
-                    "type": "string"
-                },
-                "deleted_at": {
-                    "$ref": "#/definitions/gorm.DeletedAt"
-                },
-                "email": {
-                    "type": "string"
-                },
-                "email_verified": {
+                "dry_run": {
                     "type": "boolean"
                 },
-                "id": {
-                    "type": "string"
+                "invalid": {
+                    "type": "integer"
                 },
-                "name": {
-                    "type": "string"
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/schemas.DeviceImportRow"
+                    }
                 },
-                "updated_at": {
-                    "type": "string"
+                "valid": {
+                    "type": "integer"
                 }
             }
         },
-        "models.UserSettings": {
+        "schemas.DeviceImportRow": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "deleted_at": {
-                    "$ref": "#/definitions/gorm.DeletedAt"
-                },
-                "id": {
+                "device_id": {
                     "type": "string"
                 },
-                "tracking_devices": {
+                "errors": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.GPSDevice"
+                        "type": "string"
                     }
                 },
-                "updated_at": {
+                "imei": {
                     "type": "string"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "row": {
+                    "description": "Row is the 1-based position of the device in the request, the header of a CSV file is not counted",
+                    "type": "integer"
                 }
             }
         },
-        "schemas.AddEvent": {
+        "schemas.DevicePosition": {
             "type": "object",
-            "required": [
-                "event_id"
-            ],
             "properties": {
-                "event_id": {
+                "device_id": {
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "location": {
+                    "description": "Location is the latest accepted fix, null for devices that never reported one",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GPSLocation"
+                        }
+                    ]
+                },
+                "name": {
                     "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.DeviceStatus"
                 }
             }
         },
-        "schemas.AddMember": {
+        "schemas.DeviceStats": {
             "type": "object",
-            "required": [
-                "user_id"
-            ],
             "properties": {
-                "user_id": {
+                "device_id": {
+                    "type": "string"
+                },
+                "granularity": {
                     "type": "string"
+                },
+                "odometer_meters": {
+                    "description": "OdometerMeters is the distance travelled by the device up to the end of the range",
+                    "type": "number"
+                },
+                "periods": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/schemas.DeviceStatsPeriod"
+                    }
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "total": {
+                    "$ref": "#/definitions/schemas.DeviceStatsPeriod"
                 }
             }
         },
-        "schemas.CreateAreaOfInterest": {
+        "schemas.DeviceStatsPeriod": {
             "type": "object",
             "properties": {
-                "latitude": {
+                "distance_meters": {
                     "type": "number"
                 },
-                "longitude": {
-                    "type": "number"
-                },
-                "polygon_area": {
+                "end": {
                     "type": "string"
                 },
-                "radius_in_meters": {
+                "idle_seconds": {
+                    "type": "integer"
+                },
+                "max_speed": {
                     "type": "number"
+                },
+                "moving_seconds": {
+                    "type": "integer"
+                },
+                "start": {
+                    "description": "Start and End are the first and last day of the period within the requested range",
+                    "type": "string"
                 }
             }
         },
-        "schemas.CreateComment": {
+        "schemas.Error": {
             "type": "object",
-            "required": [
-                "content"
-            ],
             "properties": {
-                "content": {
+                "error": {
                     "type": "string"
                 }
             }
         },
-        "schemas.CreateCommunity": {
+        "schemas.GPSDeviceResponse": {
             "type": "object",
-            "required": [
-                "name"
-            ],
             "properties": {
-                "allow_read_only_members_add_events": {
-                    "type": "boolean"
+                "account_id": {
+                    "type": "string"
                 },
-                "appears_in_search": {
+                "adaptive_polling": {
                     "type": "boolean"
                 },
+                "battery": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
                 "description": {
                     "type": "string"
                 },
-                "include_external_events": {
+                "has_password": {
                     "type": "boolean"
                 },
-                "name": {
+                "id": {
                     "type": "string"
                 },
-                "tracking_devices": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "imei": {
+                    "type": "string"
                 },
-                "type": {
-                    "$ref": "#/definitions/models.CommunityType"
-                }
-            }
-        },
-        "schemas.CreateCommunityInvite": {
-            "type": "object",
-            "required": [
-                "community_id",
-                "user_id"
-            ],
-            "properties": {
-                "community_id": {
+                "ingest_token": {
                     "type": "string"
                 },
-                "role": {
-                    "$ref": "#/definitions/models.MemberRole"
+                "last_error": {
+                    "type": "string"
                 },
-                "user_id": {
+                "last_error_at": {
                     "type": "string"
-                }
-            }
-        },
-        "schemas.CreateEvent": {
-            "type": "object",
-            "required": [
-                "description",
-                "title"
-            ],
-            "properties": {
-                "communities": {
+                },
+                "last_fix_at": {
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "locations": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/models.GPSLocation"
                     }
                 },
-                "description": {
+                "name": {
                     "type": "string"
                 },
-                "device_id": {
+                "number": {
                     "type": "string"
                 },
-                "is_public": {
-                    "type": "boolean"
+                "poll_failures": {
+                    "type": "integer"
                 },
-                "latitude": {
-                    "type": "number"
+                "poll_interval_seconds": {
+                    "type": "integer"
                 },
-                "longitude": {
+                "provider": {
+                    "$ref": "#/definitions/models.DeviceProviderType"
+                },
+                "retention_days": {
+                    "type": "integer"
+                },
+                "signal": {
                     "type": "number"
                 },
                 "status": {
-                    "$ref": "#/definitions/models.EventStatus"
+                    "$ref": "#/definitions/models.DeviceStatus"
                 },
-                "title": {
+                "timezone": {
                     "type": "string"
                 },
-                "type": {
-                    "$ref": "#/definitions/models.EventType"
+                "tracking": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "schemas.CreateGPSDevice": {
+        "schemas.GroupDevices": {
             "type": "object",
+            "required": [
+                "device_ids"
+            ],
             "properties": {
-                "description": {
-                    "type": "string"
-                },
-                "imei": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
+                "device_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "schemas.LocationPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GPSLocation"
+                    }
                 },
-                "number": {
+                "next_cursor": {
                     "type": "string"
-                },
-                "password": {
+                }
+            }
+        },
+        "schemas.OsmAndBatch": {
+            "type": "object",
+            "required": [
+                "id",
+                "locations"
+            ],
+            "properties": {
+                "id": {
                     "type": "string"
                 },
-                "tracking": {
-                    "type": "boolean"
+                "locations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/schemas.OsmAndLocation"
+                    }
                 }
             }
         },
-        "schemas.Error": {
+        "schemas.OsmAndLocation": {
             "type": "object",
             "properties": {
-                "error": {
+                "accuracy": {
+                    "type": "number"
+                },
+                "altitude": {
+                    "type": "number"
+                },
+                "batt": {
+                    "type": "number"
+                },
+                "bearing": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lat": {
+                    "type": "number"
+                },
+                "lon": {
+                    "type": "number"
+                },
+                "speed": {
+                    "type": "number"
+                },
+                "timestamp": {
                     "type": "string"
                 }
             }
@@ -2863,6 +6276,18 @@ const docTemplate = `{
                 }
             }
         },
+        "schemas.StreamTicket": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "ticket": {
+                    "description": "Ticket is passed as the ticket query parameter of the stream request",
+                    "type": "string"
+                }
+            }
+        },
         "schemas.TrackDevice": {
             "type": "object",
             "required": [
@@ -2874,6 +6299,20 @@ const docTemplate = `{
                 }
             }
         },
+        "schemas.TrackImportResult": {
+            "type": "object",
+            "properties": {
+                "duplicates": {
+                    "type": "integer"
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "invalid": {
+                    "type": "integer"
+                }
+            }
+        },
         "schemas.UpdateComment": {
             "type": "object",
             "properties": {
@@ -2897,6 +6336,9 @@ const docTemplate = `{
                 "include_external_events": {
                     "type": "boolean"
                 },
+                "location_retention_days": {
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -2919,6 +6361,34 @@ const docTemplate = `{
                 }
             }
         },
+        "schemas.UpdateDeviceGroup": {
+            "type": "object",
+            "properties": {
+                "adaptive_polling": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "poll_interval_seconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "schemas.UpdateDeviceShare": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
         "schemas.UpdateEvent": {
             "type": "object",
             "properties": {
@@ -2942,6 +6412,16 @@ const docTemplate = `{
         "schemas.UpdateGPSDevice": {
             "type": "object",
             "properties": {
+                "account_id": {
+                    "type": "string"
+                },
+                "adaptive_polling": {
+                    "type": "boolean"
+                },
+                "clear_account": {
+                    "description": "ClearAccount detaches the device from its provider account, it is polled with its own credentials again",
+                    "type": "boolean"
+                },
                 "description": {
                     "type": "string"
                 },
@@ -2954,6 +6434,18 @@ const docTemplate = `{
                 "number": {
                     "type": "string"
                 },
+                "password": {
+                    "type": "string"
+                },
+                "poll_interval_seconds": {
+                    "type": "integer"
+                },
+                "retention_days": {
+                    "type": "integer"
+                },
+                "timezone": {
+                    "type": "string"
+                },
                 "tracking": {
                     "type": "boolean"
                 }
@@ -2967,6 +6459,14 @@ const docTemplate = `{
                 }
             }
         },
+        "schemas.UpdateProviderAccount": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
         "schemas.UpdateUser": {
             "type": "object",
             "properties": {
@@ -0,0 +1,204 @@
+package gateway
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	gt06LoginProtocol     byte = 0x01
+	gt06LocationProtocol  byte = 0x12
+	gt06StatusProtocol    byte = 0x13
+	gt06AlarmProtocol     byte = 0x16
+	gt06Location2Protocol byte = 0x22
+	gt06Alarm2Protocol    byte = 0x26
+)
+
+var (
+	ErrInvalidStartBits = errors.New("gt06: invalid start bits")
+	ErrInvalidStopBits  = errors.New("gt06: invalid stop bits")
+	ErrInvalidCRC       = errors.New("gt06: crc mismatch")
+	ErrPacketTooShort   = errors.New("gt06: packet too short")
+)
+
+type Packet struct {
+	Protocol byte
+	Content  []byte
+	Serial   uint16
+}
+
+// Status is the terminal information block, levels are converted to percentages.
+type Status struct {
+	Battery float64
+	Signal  float64
+}
+
+type Fix struct {
+	Time       time.Time
+	Latitude   float64
+	Longitude  float64
+	Speed      float64
+	Course     uint16
+	Satellites uint8
+	Positioned bool
+}
+
+// ReadPacket reads a single short (0x7878) or long (0x7979) frame and verifies its CRC.
+func ReadPacket(r *bufio.Reader) (*Packet, error) {
+	start := make([]byte, 2)
+	if _, err := io.ReadFull(r, start); err != nil {
+		return nil, err
+	}
+
+	var header []byte
+	var length int
+
+	switch {
+	case start[0] == 0x78 && start[1] == 0x78:
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		header = []byte{b}
+		length = int(b)
+	case start[0] == 0x79 && start[1] == 0x79:
+		header = make([]byte, 2)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, err
+		}
+		length = int(binary.BigEndian.Uint16(header))
+	default:
+		return nil, ErrInvalidStartBits
+	}
+
+	// protocol number + serial + crc is the minimum body
+	if length < 5 {
+		return nil, ErrPacketTooShort
+	}
+
+	body := make([]byte, length+2)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+
+	if body[length] != 0x0D || body[length+1] != 0x0A {
+		return nil, ErrInvalidStopBits
+	}
+
+	checked := append(header, body[:length-2]...)
+	if crcITU(checked) != binary.BigEndian.Uint16(body[length-2:length]) {
+		return nil, ErrInvalidCRC
+	}
+
+	return &Packet{
+		Protocol: body[0],
+		Content:  body[1 : length-4],
+		Serial:   binary.BigEndian.Uint16(body[length-4 : length-2]),
+	}, nil
+}
+
+// EncodeAck builds the server response echoing the protocol number and serial of a packet.
+func EncodeAck(protocol byte, serial uint16) []byte {
+	frame := []byte{0x78, 0x78, 0x05, protocol, byte(serial >> 8), byte(serial)}
+	crc := crcITU(frame[2:])
+	return append(frame, byte(crc>>8), byte(crc), 0x0D, 0x0A)
+}
+
+// ParseLoginIMEI decodes the BCD encoded terminal ID of a login packet.
+func ParseLoginIMEI(content []byte) (string, error) {
+	if len(content) < 8 {
+		return "", ErrPacketTooShort
+	}
+
+	imei := hex.EncodeToString(content[:8])
+	return strings.TrimPrefix(imei, "0"), nil
+}
+
+// ParseFix decodes the date/time and GPS block shared by location and alarm packets.
+func ParseFix(content []byte) (*Fix, error) {
+	if len(content) < 18 {
+		return nil, ErrPacketTooShort
+	}
+
+	fixTime := time.Date(2000+int(content[0]), time.Month(content[1]), int(content[2]),
+		int(content[3]), int(content[4]), int(content[5]), 0, time.UTC)
+
+	latitude := float64(binary.BigEndian.Uint32(content[7:11])) / 30000.0 / 60.0
+	longitude := float64(binary.BigEndian.Uint32(content[11:15])) / 30000.0 / 60.0
+	courseStatus := binary.BigEndian.Uint16(content[16:18])
+
+	// bit 10 set means northern latitude, bit 11 set means western longitude
+	if courseStatus&0x0400 == 0 {
+		latitude = -latitude
+	}
+	if courseStatus&0x0800 != 0 {
+		longitude = -longitude
+	}
+
+	return &Fix{
+		Time:       fixTime,
+		Latitude:   latitude,
+		Longitude:  longitude,
+		Speed:      float64(content[15]),
+		Course:     courseStatus & 0x03FF,
+		Satellites: content[6] & 0x0F,
+		Positioned: courseStatus&0x1000 != 0,
+	}, nil
+}
+
+// ParseStatus decodes the terminal info, voltage level and GSM signal bytes starting at offset.
+func ParseStatus(content []byte, offset int) (*Status, error) {
+	if offset < 0 || len(content) < offset+3 {
+		return nil, ErrPacketTooShort
+	}
+
+	voltage := content[offset+1]
+	if voltage > 6 {
+		voltage = 6
+	}
+
+	signal := content[offset+2]
+	if signal > 4 {
+		signal = 4
+	}
+
+	return &Status{
+		Battery: float64(voltage) / 6 * 100,
+		Signal:  float64(signal) / 4 * 100,
+	}, nil
+}
+
+// ParseAlarmStatus locates the status block of an alarm packet, which follows the variable length LBS block.
+func ParseAlarmStatus(content []byte) (*Status, error) {
+	if len(content) < 19 {
+		return nil, ErrPacketTooShort
+	}
+
+	return ParseStatus(content, 18+int(content[18]))
+}
+
+func (p *Packet) String() string {
+	return fmt.Sprintf("gt06 packet protocol=0x%02X serial=%d content=%X", p.Protocol, p.Serial, p.Content)
+}
+
+// crcITU computes CRC-16/X-25 as used by the GT06 protocol.
+func crcITU(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = (crc >> 1) ^ 0x8408
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return ^crc
+}
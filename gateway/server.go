@@ -0,0 +1,169 @@
+package gateway
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/health"
+	"github.com/Hodik/geo-tracker-be/ingestion"
+	"github.com/Hodik/geo-tracker-be/models"
+	"gorm.io/gorm"
+)
+
+// Trackers send a heartbeat every few minutes, anything quieter is considered dead.
+const readTimeout = 10 * time.Minute
+
+func Run(db *gorm.DB, addr string) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalln("Failed to start gateway listener:", err)
+	}
+	defer listener.Close()
+
+	log.Println("GT06 gateway listening on", addr)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Println("Failed to accept connection:", err)
+			continue
+		}
+
+		go handleConnection(db, conn)
+	}
+}
+
+type session struct {
+	db     *gorm.DB
+	conn   net.Conn
+	device *models.GPSDevice
+	status *Status
+}
+
+func handleConnection(db *gorm.DB, conn net.Conn) {
+	defer conn.Close()
+
+	s := &session{db: db, conn: conn}
+	reader := bufio.NewReader(conn)
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+
+		packet, err := ReadPacket(reader)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Println("Closing gateway connection", conn.RemoteAddr(), err)
+			}
+			return
+		}
+
+		if err := s.handlePacket(packet); err != nil {
+			log.Println("Closing gateway connection", conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+func (s *session) handlePacket(packet *Packet) error {
+	if packet.Protocol != gt06LoginProtocol && s.device == nil {
+		return errors.New("packet received before login")
+	}
+
+	switch packet.Protocol {
+	case gt06LoginProtocol:
+		imei, err := ParseLoginIMEI(packet.Content)
+		if err != nil {
+			return err
+		}
+
+		var device models.GPSDevice
+		if err := s.db.Where("imei = ? AND provider = ?", imei, models.ProviderGT06).First(&device).Error; err != nil {
+			return errors.New("unknown device " + imei)
+		}
+
+		log.Println("Device", device.ID, "logged in from", s.conn.RemoteAddr())
+		s.device = &device
+		s.seen()
+		return s.ack(packet)
+
+	case gt06StatusProtocol:
+		if status, err := ParseStatus(packet.Content, 0); err == nil {
+			s.status = status
+		}
+		s.seen()
+		return s.ack(packet)
+
+	case gt06LocationProtocol, gt06Location2Protocol:
+		return s.storeFix(packet)
+
+	case gt06AlarmProtocol, gt06Alarm2Protocol:
+		log.Println("Alarm received from device", s.device.ID)
+		if status, err := ParseAlarmStatus(packet.Content); err == nil {
+			s.status = status
+		}
+		if err := s.storeFix(packet); err != nil {
+			return err
+		}
+		return s.ack(packet)
+
+	default:
+		log.Println("Ignoring unsupported", packet)
+		return nil
+	}
+}
+
+// seen keeps the device online between fixes, heartbeats are the only sign of life of a parked tracker
+func (s *session) seen() {
+	var battery, signal *float64
+	if s.status != nil {
+		battery = &s.status.Battery
+		signal = &s.status.Signal
+	}
+
+	if err := health.RecordSeen(s.db, s.device, nil, battery, signal); err != nil {
+		log.Println("Failed to record health of device", s.device.ID, err)
+	}
+}
+
+func (s *session) storeFix(packet *Packet) error {
+	fix, err := ParseFix(packet.Content)
+	if err != nil {
+		return err
+	}
+
+	if !fix.Positioned {
+		return nil
+	}
+
+	speed := fix.Speed
+	course := float64(fix.Course)
+	satellites := int(fix.Satellites)
+
+	location := &models.GPSLocation{
+		Latitude:   fix.Latitude,
+		Longitude:  fix.Longitude,
+		FixTime:    fix.Time,
+		Speed:      &speed,
+		Course:     &course,
+		Satellites: &satellites,
+	}
+
+	// Battery and signal are only reported by heartbeats, attach the last known values
+	if s.status != nil {
+		battery := s.status.Battery
+		signal := s.status.Signal
+		location.Battery = &battery
+		location.Signal = &signal
+	}
+
+	return ingestion.StoreLocations(s.db, s.device, []*models.GPSLocation{location})
+}
+
+func (s *session) ack(packet *Packet) error {
+	_, err := s.conn.Write(EncodeAck(packet.Protocol, packet.Serial))
+	return err
+}
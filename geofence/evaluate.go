@@ -0,0 +1,196 @@
+package geofence
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Evaluate walks consecutive positions of a device through its geofence rules, records the resulting
+// transitions and notifies everyone tracking the device. previous is the latest location stored
+// before locations, nil when the device has no history yet.
+func Evaluate(db *gorm.DB, device *models.GPSDevice, previous *models.GPSLocation, locations []*models.GPSLocation) error {
+	var rules []models.GeofenceRule
+	if err := db.Where("device_id = ?", device.ID).Find(&rules).Error; err != nil {
+		return err
+	}
+
+	if len(rules) == 0 || len(locations) == 0 {
+		return nil
+	}
+
+	areaIDs := make([]uuid.UUID, 0, len(rules))
+	for _, rule := range rules {
+		areaIDs = append(areaIDs, rule.AreaOfInterestID)
+	}
+
+	var states []models.GeofenceState
+	if err := db.Where("device_id = ?", device.ID).Find(&states).Error; err != nil {
+		return err
+	}
+
+	enteredAt := make(map[uuid.UUID]time.Time, len(states))
+	for _, state := range states {
+		enteredAt[state.AreaOfInterestID] = state.EnteredAt
+	}
+
+	var wasInside map[uuid.UUID]bool
+	if previous != nil {
+		var err error
+		if wasInside, err = insideAreas(db, areaIDs, previous); err != nil {
+			return err
+		}
+	}
+
+	for _, location := range locations {
+		isInside, err := insideAreas(db, areaIDs, location)
+		if err != nil {
+			return err
+		}
+
+		if err := updateStates(db, device, location, isInside, enteredAt); err != nil {
+			return err
+		}
+
+		for i := range rules {
+			rule := &rules[i]
+			was, is := wasInside[rule.AreaOfInterestID], isInside[rule.AreaOfInterestID]
+
+			fired := false
+			switch rule.Trigger {
+			case models.GeofenceEnter:
+				fired = !was && is
+			case models.GeofenceExit:
+				fired = was && !is
+			case models.GeofenceDwell:
+				if fired, err = dwellReached(db, rule, location, is, enteredAt); err != nil {
+					return err
+				}
+			}
+
+			if !fired {
+				continue
+			}
+
+			if err := recordTransition(db, device, rule, location); err != nil {
+				return err
+			}
+		}
+
+		wasInside = isInside
+	}
+
+	return nil
+}
+
+func insideAreas(db *gorm.DB, areaIDs []uuid.UUID, location *models.GPSLocation) (map[uuid.UUID]bool, error) {
+	var ids []uuid.UUID
+	if err := db.Model(&models.AreaOfInterest{}).
+		Where("id IN ? AND ST_Intersects(polygon_area, ST_SetSRID(ST_MakePoint(?, ?), 4326))", areaIDs, location.Longitude, location.Latitude).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+
+	inside := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		inside[id] = true
+	}
+
+	return inside, nil
+}
+
+func updateStates(db *gorm.DB, device *models.GPSDevice, location *models.GPSLocation, isInside map[uuid.UUID]bool, enteredAt map[uuid.UUID]time.Time) error {
+	for areaID := range isInside {
+		if _, ok := enteredAt[areaID]; ok {
+			continue
+		}
+
+		state := models.GeofenceState{DeviceID: device.ID, AreaOfInterestID: areaID, EnteredAt: location.FixTime}
+		if err := db.Create(&state).Error; err != nil {
+			return err
+		}
+
+		enteredAt[areaID] = location.FixTime
+	}
+
+	for areaID := range enteredAt {
+		if isInside[areaID] {
+			continue
+		}
+
+		if err := db.Where("device_id = ? AND area_of_interest_id = ?", device.ID, areaID).Delete(&models.GeofenceState{}).Error; err != nil {
+			return err
+		}
+
+		delete(enteredAt, areaID)
+	}
+
+	return nil
+}
+
+// A dwell rule fires once per visit, as soon as the device stayed inside long enough
+func dwellReached(db *gorm.DB, rule *models.GeofenceRule, location *models.GPSLocation, isInside bool, enteredAt map[uuid.UUID]time.Time) (bool, error) {
+	if !isInside || rule.DwellMinutes == nil {
+		return false, nil
+	}
+
+	since := enteredAt[rule.AreaOfInterestID]
+	if location.FixTime.Sub(since) < time.Duration(*rule.DwellMinutes)*time.Minute {
+		return false, nil
+	}
+
+	var count int64
+	if err := db.Model(&models.GeofenceTransition{}).
+		Where("rule_id = ? AND fix_time >= ?", rule.ID, since).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count == 0, nil
+}
+
+func recordTransition(db *gorm.DB, device *models.GPSDevice, rule *models.GeofenceRule, location *models.GPSLocation) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		transition := models.GeofenceTransition{
+			RuleID:           rule.ID,
+			DeviceID:         device.ID,
+			AreaOfInterestID: rule.AreaOfInterestID,
+			Trigger:          rule.Trigger,
+			LocationID:       location.ID,
+			Latitude:         location.Latitude,
+			Longitude:        location.Longitude,
+			FixTime:          location.FixTime,
+		}
+
+		if err := tx.Create(&transition).Error; err != nil {
+			return err
+		}
+
+		return models.NotifyDeviceTrackers(tx, device.ID, models.Notification{
+			Message:              transitionMessage(device, rule),
+			GeofenceTransitionID: &transition.ID,
+			DeviceID:             &device.ID,
+		})
+	})
+}
+
+func transitionMessage(device *models.GPSDevice, rule *models.GeofenceRule) string {
+	deviceName := device.DisplayName()
+
+	area := "an area of interest"
+	if rule.Name != nil {
+		area = *rule.Name
+	}
+
+	switch rule.Trigger {
+	case models.GeofenceEnter:
+		return fmt.Sprintf("%s entered %s", deviceName, area)
+	case models.GeofenceExit:
+		return fmt.Sprintf("%s left %s", deviceName, area)
+	default:
+		return fmt.Sprintf("%s has been in %s for %d minutes", deviceName, area, *rule.DwellMinutes)
+	}
+}
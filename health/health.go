@@ -0,0 +1,125 @@
+package health
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/config"
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/realtime"
+	"gorm.io/gorm"
+)
+
+type previousState struct {
+	Status  models.DeviceStatus
+	Battery *float64
+}
+
+// RecordSeen marks the device as heard from just now. fixTime, battery and signal are nil when the
+// message carried no position or status, the last known values are kept then.
+func RecordSeen(db *gorm.DB, device *models.GPSDevice, fixTime *time.Time, battery *float64, signal *float64) error {
+	conf := config.GetConfig(db)
+	now := time.Now().UTC()
+
+	// The previous state is read under the same row lock as the update, so concurrent messages
+	// of one device never both report it back online
+	var previous previousState
+	if err := db.Raw(`
+		UPDATE gps_devices SET
+			status = @status,
+			last_seen_at = @now,
+			last_fix_at = GREATEST(gps_devices.last_fix_at, @fix_time),
+			battery = COALESCE(@battery, gps_devices.battery),
+			signal = COALESCE(@signal, gps_devices.signal),
+			poll_failures = 0,
+			last_error = NULL
+		FROM (SELECT id, status, battery FROM gps_devices WHERE id = @id FOR UPDATE) previous
+		WHERE gps_devices.id = previous.id
+		RETURNING previous.status, previous.battery
+	`, map[string]interface{}{
+		"id":       device.ID,
+		"status":   models.DeviceStatusOnline,
+		"now":      now,
+		"fix_time": fixTime,
+		"battery":  battery,
+		"signal":   signal,
+	}).Scan(&previous).Error; err != nil {
+		return err
+	}
+
+	device.Status = models.DeviceStatusOnline
+	device.LastSeenAt = &now
+	device.PollFailures = 0
+	device.LastError = nil
+
+	if previous.Status != models.DeviceStatusOnline {
+		realtime.PublishDeviceStatus(db, device)
+	}
+
+	if previous.Status == models.DeviceStatusOffline {
+		if err := notify(db, device, fmt.Sprintf("%s is back online", device.DisplayName())); err != nil {
+			return err
+		}
+	}
+
+	wasLow := previous.Battery != nil && *previous.Battery < conf.LowBatteryPercent
+	if battery != nil && *battery < conf.LowBatteryPercent && !wasLow {
+		if err := notify(db, device, fmt.Sprintf("%s battery is low (%.0f%%)", device.DisplayName(), *battery)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordFailure counts a failed poll, devices failing repeatedly show up as needing attention
+func RecordFailure(db *gorm.DB, device *models.GPSDevice, cause error) error {
+	now := time.Now().UTC()
+
+	return db.Model(&models.GPSDevice{}).Where("id = ?", device.ID).Updates(map[string]interface{}{
+		"poll_failures": gorm.Expr("poll_failures + 1"),
+		"last_error":    cause.Error(),
+		"last_error_at": now,
+	}).Error
+}
+
+// MarkOffline flips devices that stayed silent for longer than the configured threshold to offline
+func MarkOffline(db *gorm.DB) error {
+	conf := config.GetConfig(db)
+	cutoff := time.Now().UTC().Add(-time.Duration(conf.DeviceOfflineMinutes) * time.Minute)
+
+	var devices []models.GPSDevice
+	if err := db.Where("status = ? AND last_seen_at < ?", models.DeviceStatusOnline, cutoff).Find(&devices).Error; err != nil {
+		return err
+	}
+
+	for _, device := range devices {
+		// Guarded on the status so a message arriving meanwhile keeps the device online
+		result := db.Model(&models.GPSDevice{}).
+			Where("id = ? AND status = ? AND last_seen_at < ?", device.ID, models.DeviceStatusOnline, cutoff).
+			Update("status", models.DeviceStatusOffline)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		device.Status = models.DeviceStatusOffline
+		realtime.PublishDeviceStatus(db, &device)
+
+		message := fmt.Sprintf("%s went offline, last seen %s", device.DisplayName(), device.LastSeenAt.In(device.Location()).Format("2006-01-02 15:04"))
+		if err := notify(db, &device, message); err != nil {
+			log.Default().Println("Failed to notify trackers of device", device.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func notify(db *gorm.DB, device *models.GPSDevice, message string) error {
+	return models.NotifyDeviceTrackers(db, device.ID, models.Notification{Message: message, DeviceID: &device.ID})
+}
@@ -0,0 +1,136 @@
+package ingestion
+
+import (
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/tracks"
+	"gorm.io/gorm"
+)
+
+// Positions reported within this interval are close enough in time to be smoothed together
+const smoothingMaxGap = 5 * time.Minute
+
+// A filter stage returns why location is rejected, nil when it passes. reference is the last
+// accepted position before location, nil when the device has no usable history.
+type filterStage func(conf *models.Config, reference *models.GPSLocation, location *models.GPSLocation) *models.LocationFilterReason
+
+var filterStages = []filterStage{filterNullIsland, filterLowAccuracy, filterDuplicate, filterJump}
+
+// FilterLocations runs chronologically ordered locations through the filter stages, setting FilterReason
+// on rejected points, and smooths the accepted ones when enabled. The accepted locations are returned.
+func FilterLocations(conf *models.Config, reference *models.GPSLocation, locations []*models.GPSLocation) []*models.GPSLocation {
+	accepted := make([]*models.GPSLocation, 0, len(locations))
+
+	for _, location := range locations {
+		location.FilterReason = nil
+
+		for _, stage := range filterStages {
+			if reason := stage(conf, reference, location); reason != nil {
+				location.FilterReason = reason
+				break
+			}
+		}
+
+		if location.FilterReason != nil {
+			continue
+		}
+
+		smooth(conf, reference, location)
+		accepted = append(accepted, location)
+		reference = location
+	}
+
+	return accepted
+}
+
+// filterReference loads the last accepted location of the device at or before fixTime
+func filterReference(db *gorm.DB, device *models.GPSDevice, fixTime time.Time) (*models.GPSLocation, error) {
+	var reference models.GPSLocation
+	result := db.Where("device_id = ? AND fix_time <= ? AND filter_reason IS NULL", device.ID, fixTime).
+		Order("fix_time DESC").Limit(1).Find(&reference)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	return &reference, nil
+}
+
+func filterNullIsland(conf *models.Config, reference *models.GPSLocation, location *models.GPSLocation) *models.LocationFilterReason {
+	// Trackers without a fix often report the origin instead of nothing
+	if location.Latitude == 0 && location.Longitude == 0 {
+		return filterReason(models.LocationFilterNullIsland)
+	}
+
+	return nil
+}
+
+func filterLowAccuracy(conf *models.Config, reference *models.GPSLocation, location *models.GPSLocation) *models.LocationFilterReason {
+	if conf.MaxAccuracyMeters > 0 && location.Accuracy != nil && *location.Accuracy > conf.MaxAccuracyMeters {
+		return filterReason(models.LocationFilterLowAccuracy)
+	}
+
+	return nil
+}
+
+func filterDuplicate(conf *models.Config, reference *models.GPSLocation, location *models.GPSLocation) *models.LocationFilterReason {
+	if reference != nil && location.FixTime.Equal(reference.FixTime) {
+		return filterReason(models.LocationFilterDuplicate)
+	}
+
+	return nil
+}
+
+func filterJump(conf *models.Config, reference *models.GPSLocation, location *models.GPSLocation) *models.LocationFilterReason {
+	if reference == nil || conf.MaxPlausibleSpeedKmh <= 0 {
+		return nil
+	}
+
+	elapsed := location.FixTime.Sub(reference.FixTime).Seconds()
+	if elapsed <= 0 {
+		return nil
+	}
+
+	if tracks.Distance(referencePosition(reference), location)/elapsed*3.6 > conf.MaxPlausibleSpeedKmh {
+		return filterReason(models.LocationFilterJump)
+	}
+
+	return nil
+}
+
+// smooth pulls location towards the previous accepted position, keeping the reported position in the raw fields
+func smooth(conf *models.Config, reference *models.GPSLocation, location *models.GPSLocation) {
+	if conf.LocationSmoothing <= 0 || conf.LocationSmoothing >= 1 || reference == nil {
+		return
+	}
+
+	if location.FixTime.Sub(reference.FixTime) > smoothingMaxGap {
+		return
+	}
+
+	latitude := location.Latitude
+	longitude := location.Longitude
+	location.RawLatitude = &latitude
+	location.RawLongitude = &longitude
+
+	location.Latitude = conf.LocationSmoothing*reference.Latitude + (1-conf.LocationSmoothing)*latitude
+	location.Longitude = conf.LocationSmoothing*reference.Longitude + (1-conf.LocationSmoothing)*longitude
+}
+
+// referencePosition is the reported position of reference, jumps are measured between raw fixes
+func referencePosition(reference *models.GPSLocation) *models.GPSLocation {
+	if reference.RawLatitude == nil || reference.RawLongitude == nil {
+		return reference
+	}
+
+	return &models.GPSLocation{Latitude: *reference.RawLatitude, Longitude: *reference.RawLongitude, FixTime: reference.FixTime}
+}
+
+func filterReason(reason models.LocationFilterReason) *models.LocationFilterReason {
+	return &reason
+}
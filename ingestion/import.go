@@ -0,0 +1,103 @@
+package ingestion
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/config"
+	"github.com/Hodik/geo-tracker-be/models"
+	"gorm.io/gorm"
+)
+
+type ImportResult struct {
+	Imported   int
+	Duplicates int
+	Invalid    int
+}
+
+// ImportLocations stores historical points, dropping invalid ones and any point whose
+// fix time is already present in the file or in the device history.
+func ImportLocations(db *gorm.DB, device *models.GPSDevice, locations []*models.GPSLocation) (*ImportResult, error) {
+	result := &ImportResult{}
+	now := time.Now()
+
+	valid := make([]*models.GPSLocation, 0, len(locations))
+	for _, location := range locations {
+		if !isValidImport(location, now) {
+			result.Invalid++
+			continue
+		}
+		valid = append(valid, location)
+	}
+
+	if len(valid) == 0 {
+		return result, nil
+	}
+
+	sort.SliceStable(valid, func(i, j int) bool {
+		return valid[i].FixTime.Before(valid[j].FixTime)
+	})
+
+	var existing []time.Time
+	if err := db.Model(&models.GPSLocation{}).
+		Where("device_id = ? AND fix_time BETWEEN ? AND ?", device.ID, valid[0].FixTime, valid[len(valid)-1].FixTime).
+		Pluck("fix_time", &existing).Error; err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int64]bool, len(existing)+len(valid))
+	for _, fixTime := range existing {
+		seen[fixTime.UnixNano()] = true
+	}
+
+	unique := make([]*models.GPSLocation, 0, len(valid))
+	for _, location := range valid {
+		key := location.FixTime.UnixNano()
+		if seen[key] {
+			result.Duplicates++
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, location)
+	}
+
+	if len(unique) > 0 {
+		reference, err := filterReference(db, device, unique[0].FixTime)
+		if err != nil {
+			return nil, err
+		}
+
+		// Implausible points are imported marked, like live ones
+		FilterLocations(config.GetConfig(db), reference, unique)
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		// Imported history is in the past, it doesn't trigger geofence alerts
+		if err := insertLocations(tx, device, unique); err != nil {
+			return err
+		}
+
+		if len(unique) == 0 {
+			return nil
+		}
+
+		return models.InvalidateTripsSince(tx, device.ID, unique[0].FixTime)
+	}); err != nil {
+		return nil, err
+	}
+
+	result.Imported = len(unique)
+	return result, nil
+}
+
+func isValidImport(location *models.GPSLocation, now time.Time) bool {
+	if location.Latitude < -90 || location.Latitude > 90 || location.Longitude < -180 || location.Longitude > 180 {
+		return false
+	}
+
+	if location.Latitude == 0 && location.Longitude == 0 {
+		return false
+	}
+
+	return !location.FixTime.IsZero() && !location.FixTime.After(now)
+}
@@ -0,0 +1,109 @@
+package ingestion
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/config"
+	"github.com/Hodik/geo-tracker-be/geofence"
+	"github.com/Hodik/geo-tracker-be/health"
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/realtime"
+	"gorm.io/gorm"
+)
+
+// StoreLocations is the single entry point for persisting positions of a device,
+// regardless of whether they were polled, pushed over TCP or uploaded over HTTP.
+func StoreLocations(db *gorm.DB, device *models.GPSDevice, locations []*models.GPSLocation) error {
+	if len(locations) == 0 {
+		return nil
+	}
+
+	for _, location := range locations {
+		// Providers that don't report a fix time are assumed to be real time
+		if location.FixTime.IsZero() {
+			location.FixTime = time.Now().UTC()
+		}
+	}
+
+	sort.SliceStable(locations, func(i, j int) bool {
+		return locations[i].FixTime.Before(locations[j].FixTime)
+	})
+
+	var previous *models.GPSLocation
+	var latest models.GPSLocation
+	result := db.Where("device_id = ? AND filter_reason IS NULL", device.ID).Order("fix_time DESC").Limit(1).Find(&latest)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		previous = &latest
+	}
+
+	reference := previous
+	if previous != nil && !locations[0].FixTime.After(previous.FixTime) {
+		var err error
+		if reference, err = filterReference(db, device, locations[0].FixTime); err != nil {
+			return err
+		}
+	}
+
+	// Rejected points are stored as well, marked with the reason, so the history stays auditable
+	accepted := FilterLocations(config.GetConfig(db), reference, locations)
+
+	if err := insertLocations(db, device, locations); err != nil {
+		return err
+	}
+
+	// Buffered batches may arrive after the worker segmented the history they belong to. Only accepted points
+	// older than the latest fix change that history, a parked device polled again repeats the same fix.
+	if previous != nil && len(accepted) > 0 && accepted[0].FixTime.Before(previous.FixTime) {
+		if err := models.InvalidateTripsSince(db, device.ID, accepted[0].FixTime); err != nil {
+			log.Default().Println("Failed to invalidate trips of device", device.ID, err)
+		}
+	}
+
+	// Only positions newer than the stored history can move the device across a geofence
+	var live []*models.GPSLocation
+	for _, location := range accepted {
+		if previous == nil || location.FixTime.After(previous.FixTime) {
+			live = append(live, location)
+		}
+	}
+
+	newest := locations[len(locations)-1]
+	var fixTime *time.Time
+	if len(accepted) > 0 {
+		fixTime = &accepted[len(accepted)-1].FixTime
+	}
+
+	if err := health.RecordSeen(db, device, fixTime, newest.Battery, newest.Signal); err != nil {
+		log.Default().Println("Failed to record health of device", device.ID, err)
+	}
+
+	if len(live) > 0 {
+		realtime.PublishLocation(db, live[len(live)-1])
+	}
+
+	// The locations are stored already, a failing alert must not make the provider resend them
+	if err := geofence.Evaluate(db, device, previous, live); err != nil {
+		log.Default().Println("Failed to evaluate geofences of device", device.ID, err)
+	}
+
+	return nil
+}
+
+func insertLocations(db *gorm.DB, device *models.GPSDevice, locations []*models.GPSLocation) error {
+	if len(locations) == 0 {
+		return nil
+	}
+
+	for _, location := range locations {
+		location.DeviceID = device.ID
+	}
+
+	return db.CreateInBatches(&locations, 500).Error
+}
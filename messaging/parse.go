@@ -0,0 +1,89 @@
+package messaging
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Hodik/geo-tracker-be/models"
+)
+
+var ErrNoLocation = errors.New("no location found in message")
+
+const coordinatePattern = `[NSEWnsew]?\s*[-+]?\d{1,3}(?:\.\d+)?\s*[NSEWnsew]?`
+
+var (
+	// maps.google.com/maps?f=q&q=22.54321,114.12345&z=16, maps.google.com/?q=N22.54321,E114.12345, ?q=loc:...
+	mapsQueryRegex = regexp.MustCompile(`[?&]q=(?:loc:)?(` + coordinatePattern + `)\s*,\s*(` + coordinatePattern + `)`)
+	// google.com/maps/place/.../@22.54321,114.12345,17z
+	mapsAtRegex = regexp.MustCompile(`/@([-+]?\d{1,3}(?:\.\d+)?),([-+]?\d{1,3}(?:\.\d+)?)`)
+	// lat:22.543210 long:114.123456, Lat: N22.54321, Lon: E114.12345
+	textRegex  = regexp.MustCompile(`(?i)\blat(?:itude)?\s*[:=]?\s*(` + coordinatePattern + `)[\s,;]*(?:longitude|long|lng|lon)\s*[:=]?\s*(` + coordinatePattern + `)`)
+	speedRegex = regexp.MustCompile(`(?i)\bspeed\s*[:=]?\s*(\d+(?:\.\d+)?)`)
+)
+
+// ParseLocation extracts a position from the SMS reply of a tracker. Replies rarely carry a usable
+// timezone, so the fix time is left empty and the location is stamped on arrival.
+func ParseLocation(body string) (*models.GPSLocation, error) {
+	var match []string
+	for _, regex := range []*regexp.Regexp{mapsQueryRegex, mapsAtRegex, textRegex} {
+		if match = regex.FindStringSubmatch(body); match != nil {
+			break
+		}
+	}
+
+	if match == nil {
+		return nil, ErrNoLocation
+	}
+
+	latitude, err := parseCoordinate(match[1], "S")
+	if err != nil {
+		return nil, err
+	}
+
+	longitude, err := parseCoordinate(match[2], "W")
+	if err != nil {
+		return nil, err
+	}
+
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return nil, errors.New("coordinates out of range")
+	}
+
+	// Trackers without a fix report zeros instead of omitting the position
+	if latitude == 0 && longitude == 0 {
+		return nil, ErrNoLocation
+	}
+
+	location := &models.GPSLocation{Latitude: latitude, Longitude: longitude}
+
+	if match := speedRegex.FindStringSubmatch(body); match != nil {
+		if speed, err := strconv.ParseFloat(match[1], 64); err == nil {
+			location.Speed = &speed
+		}
+	}
+
+	return location, nil
+}
+
+func parseCoordinate(value string, negativeHemisphere string) (float64, error) {
+	value = strings.ToUpper(strings.ReplaceAll(value, " ", ""))
+
+	negative := false
+	if trimmed := strings.Trim(value, "NSEW"); trimmed != value {
+		negative = strings.Contains(value, negativeHemisphere)
+		value = trimmed
+	}
+
+	coordinate, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if negative {
+		coordinate = -coordinate
+	}
+
+	return coordinate, nil
+}
@@ -4,6 +4,7 @@ import (
 	api "github.com/twilio/twilio-go/rest/api/v2010"
 )
 
+// Send delivers an SMS and returns the SID Twilio assigned to the message
 func Send(to string, msg string) (*string, error) {
 
 	if TwilioClient == nil {
@@ -15,9 +16,13 @@ func Send(to string, msg string) (*string, error) {
 	params.SetFrom(TwilioPhoneNumber)
 	params.SetTo(to)
 
+	if TwilioStatusCallbackUrl != "" {
+		params.SetStatusCallback(TwilioStatusCallbackUrl)
+	}
+
 	resp, err := TwilioClient.Api.CreateMessage(params)
 	if err != nil {
 		return nil, err
 	}
-	return resp.Body, nil
+	return resp.Sid, nil
 }
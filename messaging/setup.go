@@ -10,6 +10,11 @@ var TwilioClient *twilio.RestClient
 var TwilioPhoneNumber string
 var TwilioAuthToken string
 var TwilioWebhookUrl string
+var TwilioStatusCallbackUrl string
+
+func IsConfigured() bool {
+	return TwilioClient != nil
+}
 
 func Setup() {
 	TwilioClient = twilio.NewRestClient()
@@ -32,4 +37,7 @@ func Setup() {
 		panic("TWILIO_WEBHOOK_URL is required")
 	}
 
+	// Optional, without it commands never move from sent to delivered
+	TwilioStatusCallbackUrl = os.Getenv("TWILIO_STATUS_CALLBACK_URL")
+
 }
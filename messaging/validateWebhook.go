@@ -8,6 +8,18 @@ import (
 )
 
 func ValidateWebhook(c *gin.Context) error {
+	return validateRequest(c, TwilioWebhookUrl)
+}
+
+func ValidateStatusCallback(c *gin.Context) error {
+	if TwilioStatusCallbackUrl == "" {
+		return errors.New("status callback is not configured")
+	}
+
+	return validateRequest(c, TwilioStatusCallbackUrl)
+}
+
+func validateRequest(c *gin.Context, url string) error {
 	params := make(map[string]string)
 	c.Request.ParseForm()
 	for key, value := range c.Request.PostForm {
@@ -18,7 +30,7 @@ func ValidateWebhook(c *gin.Context) error {
 
 	signature := c.Request.Header.Get("X-Twilio-Signature")
 
-	if !requestValidator.Validate(TwilioWebhookUrl, params, signature) {
+	if !requestValidator.Validate(url, params, signature) {
 		return errors.New("invalid signature")
 	}
 
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// StreamTicket authenticates the request with the single use ticket from the ticket query parameter.
+// Browsers can't set headers on WebSocket and EventSource requests, so it is only used for streaming routes.
+func StreamTicket(c *gin.Context) {
+	ticket := c.Query("ticket")
+	if ticket == "" {
+		c.Next()
+		return
+	}
+
+	db := c.MustGet("db").(*gorm.DB)
+
+	user, err := models.RedeemStreamTicket(db, ticket)
+	if errors.Is(err, models.ErrInvalidStreamTicket) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		c.Abort()
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to redeem ticket"})
+		c.Abort()
+		return
+	}
+
+	c.Set("user", user)
+	c.Next()
+}
+
+// UnlessAuthenticated runs handler only for requests without a user yet, e.g. not authenticated by a ticket
+func UnlessAuthenticated(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("user"); ok {
+			c.Next()
+			return
+		}
+
+		handler(c)
+	}
+}
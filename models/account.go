@@ -0,0 +1,18 @@
+package models
+
+import (
+	"github.com/Hodik/geo-tracker-be/secrets"
+	"github.com/google/uuid"
+)
+
+// ProviderAccount is a vendor login owning one or more trackers, all its devices are polled with one session.
+type ProviderAccount struct {
+	Base
+	Provider    DeviceProviderType `gorm:"type:device_provider;not null;default:'365gps';uniqueIndex:idx_provider_accounts_provider_username" json:"provider"`
+	Username    string             `gorm:"not null;uniqueIndex:idx_provider_accounts_provider_username" json:"username"`
+	Password    secrets.Secret     `gorm:"not null" json:"-"`
+	APICookie   *secrets.Secret    `json:"-"`
+	Devices     []*GPSDevice       `gorm:"foreignKey:AccountID" json:"-"`
+	CreatedByID uuid.UUID          `gorm:"not null;index" json:"created_by_id"`
+	CreatedBy   *User              `json:"-"`
+}
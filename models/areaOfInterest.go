@@ -16,6 +16,23 @@ type AreaOfInterest struct {
 	Events         []*Event `gorm:"many2many:event_areas_of_interest" json:"events"`
 }
 
+// AreasOfInterestVisibleTo selects the IDs of the areas of interest of the user, of the communities the user
+// is a member of and of the communities appearing in search, to be used as a subquery
+func AreasOfInterestVisibleTo(db *gorm.DB, user *User) *gorm.DB {
+	return db.Raw(`
+		SELECT user_areas_of_interest.area_of_interest_id FROM user_areas_of_interest WHERE user_areas_of_interest.user_id = @user
+		UNION
+		SELECT community_areas_of_interest.area_of_interest_id
+		FROM community_areas_of_interest
+		JOIN communities ON communities.id = community_areas_of_interest.community_id AND communities.deleted_at IS NULL
+		WHERE communities.appears_in_search
+			OR EXISTS (
+				SELECT 1 FROM community_members
+				WHERE community_members.community_id = communities.id AND community_members.user_id = @user
+			)
+	`, map[string]interface{}{"user": user.ID})
+}
+
 func (a *AreaOfInterest) PopulateEvents(db *gorm.DB) (err error) {
 	events, err := a.GetEvents(db)
 
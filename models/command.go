@@ -0,0 +1,58 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DeviceCommand struct {
+	Base
+	DeviceID       uuid.UUID           `gorm:"not null;index" json:"device_id"`
+	Device         *GPSDevice          `json:"-"`
+	Name           string              `gorm:"not null" json:"name"`
+	Payload        string              `gorm:"not null" json:"payload"`
+	Retryable      bool                `gorm:"not null;default:false" json:"retryable"`
+	Status         DeviceCommandStatus `gorm:"type:device_command_status;not null;default:'pending';index" json:"status"`
+	MessageSID     *string             `gorm:"index" json:"message_sid"`
+	Attempts       int                 `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  *time.Time          `gorm:"index" json:"next_attempt_at"`
+	LastError      *string             `json:"last_error"`
+	Response       *string             `json:"response"`
+	SentAt         *time.Time          `json:"sent_at"`
+	DeliveredAt    *time.Time          `json:"delivered_at"`
+	AcknowledgedAt *time.Time          `json:"acknowledged_at"`
+	CreatedBy      *User               `json:"-"`
+	CreatedByID    uuid.UUID           `gorm:"not null;index" json:"created_by_id"`
+}
+
+type DeviceCommandStatus string
+
+const (
+	CommandPending      DeviceCommandStatus = "pending"
+	CommandSending      DeviceCommandStatus = "sending"
+	CommandSent         DeviceCommandStatus = "sent"
+	CommandDelivered    DeviceCommandStatus = "delivered"
+	CommandAcknowledged DeviceCommandStatus = "acknowledged"
+	CommandFailed       DeviceCommandStatus = "failed"
+)
+
+var DeviceCommandStatuses = []DeviceCommandStatus{CommandPending, CommandSending, CommandSent, CommandDelivered, CommandAcknowledged, CommandFailed}
+
+func (s DeviceCommandStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+func (s *DeviceCommandStatus) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*s = DeviceCommandStatus(v)
+	case []byte:
+		*s = DeviceCommandStatus(string(v))
+	default:
+		return fmt.Errorf("unsupported scan type for DeviceCommandStatus: %T", value)
+	}
+	return nil
+}
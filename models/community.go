@@ -25,6 +25,7 @@ type Community struct {
 	AppearsInSearch               *bool              `gorm:"not null;default:true;index" json:"appears_in_search"`
 	IncludeExternalEvents         *bool              `gorm:"not null;default:true" json:"include_external_events"`
 	AllowReadOnlyMembersAddEvents *bool              `gorm:"not null;default:true" json:"allow_read_only_members_add_events"`
+	LocationRetentionDays         *int               `json:"location_retention_days"`
 	Members                       []*CommunityMember `json:"members"`
 	TrackingDevices               []*GPSDevice       `gorm:"many2many:community_tracking" json:"tracking_devices"`
 	Events                        []*Event           `gorm:"many2many:event_communities" json:"events"`
@@ -172,7 +173,7 @@ func (c *Community) IsEventLinked(event *Event) bool {
 }
 
 func (community *Community) Fetch(db *gorm.DB, id string) error {
-	if err := db.Select("communities.created_at, communities.deleted_at, communities.updated_at, communities.id, communities.name, communities.description, type, communities.appears_in_search, communities.include_external_events, communities.allow_read_only_members_add_events").Preload("Events", func(db *gorm.DB) *gorm.DB {
+	if err := db.Select("communities.created_at, communities.deleted_at, communities.updated_at, communities.id, communities.name, communities.description, type, communities.appears_in_search, communities.include_external_events, communities.allow_read_only_members_add_events, communities.location_retention_days").Preload("Events", func(db *gorm.DB) *gorm.DB {
 		return db.Order("created_at DESC")
 	}).Preload("TrackingDevices", func(db *gorm.DB) *gorm.DB {
 		return db.Order("created_at DESC")
@@ -1,7 +1,82 @@
 package models
 
+import "log"
+
+// Polling a provider more than once every few seconds gets accounts blocked, and a device polled less
+// than daily is better off untracked
+const (
+	MinPollIntervalSeconds = 10
+	MaxPollIntervalSeconds = 86400
+)
+
 type Config struct {
-	PollInterval    uint8  `gorm:"default:30" json:"poll_interval"`
+	PollInterval    uint   `gorm:"default:30" json:"poll_interval"`
 	Dummy           string `gorm:"unique;default:'singleton'" json:"-"`
 	MediaBucketName string `gorm:"default:geotracker-media;not null" json:"media_bucket_name"`
+
+	PollWorkers                uint `gorm:"default:10;not null" json:"poll_workers"`
+	PollTimeoutSeconds         uint `gorm:"default:20;not null" json:"poll_timeout_seconds"`
+	PollBackoffMaxMinutes      uint `gorm:"default:15;not null" json:"poll_backoff_max_minutes"`
+	PollCircuitFailures        uint `gorm:"default:5;not null" json:"poll_circuit_failures"`
+	PollCircuitCooldownMinutes uint `gorm:"default:30;not null" json:"poll_circuit_cooldown_minutes"`
+
+	// AdaptivePolling is the default of devices and groups that don't set it
+	AdaptivePolling bool `gorm:"default:false;not null" json:"adaptive_polling"`
+	// Adaptive devices are polled at most PollMovingSeconds apart while moving or involved in an open event,
+	// and at least PollStationarySeconds or PollOfflineSeconds apart otherwise
+	PollMovingSeconds     uint    `gorm:"default:15;not null" json:"poll_moving_seconds"`
+	PollStationarySeconds uint    `gorm:"default:120;not null" json:"poll_stationary_seconds"`
+	PollOfflineSeconds    uint    `gorm:"default:600;not null" json:"poll_offline_seconds"`
+	MovingSpeedKmh        float64 `gorm:"default:5;not null" json:"moving_speed_kmh"`
+
+	LocationRetentionDays       uint `gorm:"default:30;not null" json:"location_retention_days"`
+	LocationDownsampleAfterDays uint `gorm:"default:7;not null" json:"location_downsample_after_days"`
+	LocationDownsampleMinutes   uint `gorm:"default:0;not null" json:"location_downsample_minutes"`
+	RetentionJobMinutes         uint `gorm:"default:60;not null" json:"retention_job_minutes"`
+	RetentionBatchSize          uint `gorm:"default:5000;not null" json:"retention_batch_size"`
+
+	TripJobMinutes        uint    `gorm:"default:5;not null" json:"trip_job_minutes"`
+	StopRadiusMeters      float64 `gorm:"default:100;not null" json:"stop_radius_meters"`
+	StopMinMinutes        uint    `gorm:"default:5;not null" json:"stop_min_minutes"`
+	TripMinDistanceMeters float64 `gorm:"default:200;not null" json:"trip_min_distance_meters"`
+
+	StatsJobMinutes uint `gorm:"default:15;not null" json:"stats_job_minutes"`
+	// Gaps between two fixes longer than StatsMaxGapMinutes count as neither moving nor idle time
+	StatsMaxGapMinutes uint `gorm:"default:10;not null" json:"stats_max_gap_minutes"`
+
+	CommandMaxAttempts       uint `gorm:"default:3;not null" json:"command_max_attempts"`
+	CommandRetrySeconds      uint `gorm:"default:30;not null" json:"command_retry_seconds"`
+	CommandAckTimeoutMinutes uint `gorm:"default:10;not null" json:"command_ack_timeout_minutes"`
+
+	DeviceOfflineMinutes  uint    `gorm:"default:30;not null" json:"device_offline_minutes"`
+	LowBatteryPercent     float64 `gorm:"default:20;not null" json:"low_battery_percent"`
+	HealthJobMinutes      uint    `gorm:"default:1;not null" json:"health_job_minutes"`
+	AttentionPollFailures int     `gorm:"default:3;not null" json:"attention_poll_failures"`
+
+	MaxPlausibleSpeedKmh float64 `gorm:"default:300;not null" json:"max_plausible_speed_kmh"`
+	MaxAccuracyMeters    float64 `gorm:"default:500;not null" json:"max_accuracy_meters"`
+	// LocationSmoothing is the weight of the previous position when smoothing, 0 disables it
+	LocationSmoothing float64 `gorm:"default:0;not null" json:"location_smoothing"`
+}
+
+// ApplyMinimums raises settings below what the jobs can safely run with. The config is a plain row edited by
+// hand, a zero batch size would never finish deleting and a zero interval would run a job back to back.
+func (c *Config) ApplyMinimums() {
+	raiseSetting(&c.LocationRetentionDays, 1, "location_retention_days")
+	raiseSetting(&c.RetentionJobMinutes, 1, "retention_job_minutes")
+	raiseSetting(&c.RetentionBatchSize, 100, "retention_batch_size")
+	raiseSetting(&c.TripJobMinutes, 1, "trip_job_minutes")
+	raiseSetting(&c.StatsJobMinutes, 1, "stats_job_minutes")
+	raiseSetting(&c.HealthJobMinutes, 1, "health_job_minutes")
+	raiseSetting(&c.PollInterval, MinPollIntervalSeconds, "poll_interval")
+	raiseSetting(&c.PollMovingSeconds, MinPollIntervalSeconds, "poll_moving_seconds")
+	raiseSetting(&c.PollStationarySeconds, MinPollIntervalSeconds, "poll_stationary_seconds")
+	raiseSetting(&c.PollOfflineSeconds, MinPollIntervalSeconds, "poll_offline_seconds")
+}
+
+func raiseSetting(value *uint, minimum uint, name string) {
+	if *value < minimum {
+		log.Printf("Config %s is %d, using the minimum of %d", name, *value, minimum)
+		*value = minimum
+	}
 }
@@ -1,27 +1,193 @@
 package models
 
 import (
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/secrets"
 	"github.com/google/uuid"
 )
 
 type GPSDevice struct {
 	Base
-	Imei        *string       `gorm:"unique;index" json:"imei"`
-	Password    *string       `json:"password"`
-	Tracking    *bool         `gorm:"default:true;not null" json:"tracking"`
-	APICookie   *string       `json:"api_cookie"`
-	Number      *string       `gorm:"unique;index" json:"number"`
-	Locations   []GPSLocation `gorm:"foreignKey:DeviceID" json:"locations"`
-	CreatedByID *uuid.UUID    `gorm:"index" json:"created_by"`
-	CreatedBY   *User         `json:"-"`
-	Name        *string       `json:"name"`
-	Description *string       `json:"description"`
+	Imei          *string            `gorm:"unique;index" json:"imei"`
+	Password      *secrets.Secret    `json:"-"`
+	Tracking      *bool              `gorm:"default:true;not null" json:"tracking"`
+	Provider      DeviceProviderType `gorm:"type:device_provider;not null;default:'365gps'" json:"provider"`
+	APICookie     *secrets.Secret    `json:"-"`
+	AccountID     *uuid.UUID         `gorm:"index" json:"account_id"`
+	Account       *ProviderAccount   `json:"-"`
+	IngestToken   *string            `gorm:"unique;index" json:"-"`
+	Number        *string            `gorm:"unique;index" json:"number"`
+	Locations     []GPSLocation      `gorm:"foreignKey:DeviceID" json:"locations"`
+	CreatedByID   *uuid.UUID         `gorm:"index" json:"created_by"`
+	CreatedBY     *User              `json:"-"`
+	Name          *string            `json:"name"`
+	Description   *string            `json:"description"`
+	Timezone      *string            `json:"timezone"`
+	RetentionDays *int               `json:"retention_days"`
+	// PollIntervalSeconds overrides the poll interval of the groups of the device and of the config
+	PollIntervalSeconds *uint `json:"poll_interval_seconds"`
+	// AdaptivePolling overrides whether the interval follows the movement of the device, like PollIntervalSeconds
+	AdaptivePolling *bool `json:"adaptive_polling"`
+
+	Status       DeviceStatus `gorm:"type:device_status;not null;default:'unknown';index" json:"status"`
+	LastSeenAt   *time.Time   `gorm:"index" json:"last_seen_at"`
+	LastFixAt    *time.Time   `json:"last_fix_at"`
+	Battery      *float64     `json:"battery"`
+	Signal       *float64     `json:"signal"`
+	PollFailures int          `gorm:"not null;default:0" json:"poll_failures"`
+	LastError    *string      `json:"last_error"`
+	LastErrorAt  *time.Time   `json:"last_error_at"`
 }
 
 type GPSLocation struct {
 	Base
-	Latitude  float64    `gorm:"not null" json:"latitude"`
-	Longitude float64    `gorm:"not null" json:"longitude"`
-	DeviceID  uuid.UUID  `gorm:"index" json:"device_id"`
-	Device    *GPSDevice `json:"-"`
+	Latitude   float64    `gorm:"not null" json:"latitude"`
+	Longitude  float64    `gorm:"not null" json:"longitude"`
+	DeviceID   uuid.UUID  `gorm:"index;index:idx_gps_locations_device_fix_time,priority:1" json:"device_id"`
+	Device     *GPSDevice `json:"-"`
+	FixTime    time.Time  `gorm:"index:idx_gps_locations_device_fix_time,priority:2" json:"fix_time"`
+	Speed      *float64   `json:"speed"`
+	Course     *float64   `json:"course"`
+	Altitude   *float64   `json:"altitude"`
+	Satellites *int       `json:"satellites"`
+	Accuracy   *float64   `json:"accuracy"`
+	Battery    *float64   `json:"battery"`
+	Signal     *float64   `json:"signal"`
+	// FilterReason is set on points rejected on ingestion, they are kept for auditing but left out of tracks, trips and alerts
+	FilterReason *LocationFilterReason `gorm:"type:location_filter_reason;index" json:"filter_reason"`
+	// RawLatitude and RawLongitude keep the reported position of points moved by smoothing
+	RawLatitude  *float64 `json:"raw_latitude"`
+	RawLongitude *float64 `json:"raw_longitude"`
+}
+
+type DeviceProviderType string
+
+const (
+	Provider365GPS DeviceProviderType = "365gps"
+	ProviderGT06   DeviceProviderType = "gt06"
+	ProviderOsmAnd DeviceProviderType = "osmand"
+	// ProviderSimulator devices are moved by the simulator mode, nothing polls them
+	ProviderSimulator DeviceProviderType = "simulator"
+)
+
+var DeviceProviderTypes = []DeviceProviderType{Provider365GPS, ProviderGT06, ProviderOsmAnd, ProviderSimulator}
+
+type DeviceStatus string
+
+const (
+	DeviceStatusUnknown DeviceStatus = "unknown"
+	DeviceStatusOnline  DeviceStatus = "online"
+	DeviceStatusOffline DeviceStatus = "offline"
+)
+
+var DeviceStatuses = []DeviceStatus{DeviceStatusUnknown, DeviceStatusOnline, DeviceStatusOffline}
+
+type LocationFilterReason string
+
+const (
+	LocationFilterNullIsland  LocationFilterReason = "null_island"
+	LocationFilterDuplicate   LocationFilterReason = "duplicate"
+	LocationFilterLowAccuracy LocationFilterReason = "low_accuracy"
+	LocationFilterJump        LocationFilterReason = "jump"
+)
+
+var LocationFilterReasons = []LocationFilterReason{LocationFilterNullIsland, LocationFilterDuplicate, LocationFilterLowAccuracy, LocationFilterJump}
+
+func ValidateDeviceProvider(p string) error {
+	for _, provider := range DeviceProviderTypes {
+		if p == string(provider) {
+			return nil
+		}
+	}
+
+	return errors.New("invalid device provider")
+}
+
+// GenerateIngestToken sets a random token push-based devices use to authenticate their uploads.
+func (d *GPSDevice) GenerateIngestToken() error {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+
+	token := hex.EncodeToString(b)
+	d.IngestToken = &token
+	return nil
+}
+
+// Location returns the timezone the device reports local times in, UTC when unset.
+func (d *GPSDevice) Location() *time.Location {
+	if d.Timezone == nil {
+		return time.UTC
+	}
+
+	location, err := time.LoadLocation(*d.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+func (p DeviceProviderType) Value() (driver.Value, error) {
+	return string(p), nil
+}
+
+func (s DeviceStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+func (s *DeviceStatus) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*s = DeviceStatus(v)
+	case []byte:
+		*s = DeviceStatus(string(v))
+	default:
+		return fmt.Errorf("unsupported scan type for DeviceStatus: %T", value)
+	}
+	return nil
+}
+
+func (r LocationFilterReason) Value() (driver.Value, error) {
+	return string(r), nil
+}
+
+func (r *LocationFilterReason) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*r = LocationFilterReason(v)
+	case []byte:
+		*r = LocationFilterReason(string(v))
+	default:
+		return fmt.Errorf("unsupported scan type for LocationFilterReason: %T", value)
+	}
+	return nil
+}
+
+func (p *DeviceProviderType) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*p = DeviceProviderType(v)
+	case []byte:
+		*p = DeviceProviderType(string(v))
+	default:
+		return fmt.Errorf("unsupported scan type for DeviceProviderType: %T", value)
+	}
+	return nil
+}
+
+// DisplayName is the name shown to users in notifications and exports
+func (d *GPSDevice) DisplayName() string {
+	if d.Name != nil {
+		return *d.Name
+	}
+
+	return d.ID.String()
 }
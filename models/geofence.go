@@ -0,0 +1,80 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GeofenceRule struct {
+	Base
+	Name             *string         `json:"name"`
+	DeviceID         uuid.UUID       `gorm:"not null;index" json:"device_id"`
+	Device           *GPSDevice      `json:"-"`
+	AreaOfInterestID uuid.UUID       `gorm:"not null;index" json:"area_of_interest_id"`
+	AreaOfInterest   *AreaOfInterest `json:"area_of_interest"`
+	Trigger          GeofenceTrigger `gorm:"type:geofence_trigger;not null" json:"trigger"`
+	DwellMinutes     *uint           `json:"dwell_minutes"`
+	CreatedBy        *User           `json:"-"`
+	CreatedByID      uuid.UUID       `gorm:"not null;index" json:"created_by_id"`
+}
+
+type GeofenceTransition struct {
+	Base
+	RuleID           uuid.UUID       `gorm:"not null;index" json:"rule_id"`
+	Rule             *GeofenceRule   `json:"-"`
+	DeviceID         uuid.UUID       `gorm:"not null;index:idx_geofence_transitions_device_fix_time,priority:1" json:"device_id"`
+	Device           *GPSDevice      `json:"-"`
+	AreaOfInterestID uuid.UUID       `gorm:"not null;index" json:"area_of_interest_id"`
+	Trigger          GeofenceTrigger `gorm:"type:geofence_trigger;not null" json:"trigger"`
+	LocationID       uuid.UUID       `gorm:"not null" json:"location_id"`
+	Latitude         float64         `gorm:"not null" json:"latitude"`
+	Longitude        float64         `gorm:"not null" json:"longitude"`
+	FixTime          time.Time       `gorm:"not null;index:idx_geofence_transitions_device_fix_time,priority:2" json:"fix_time"`
+}
+
+// GeofenceState exists while a device is inside an area of interest, dwell triggers are measured from EnteredAt.
+type GeofenceState struct {
+	DeviceID         uuid.UUID `gorm:"primaryKey" json:"device_id"`
+	AreaOfInterestID uuid.UUID `gorm:"primaryKey" json:"area_of_interest_id"`
+	EnteredAt        time.Time `gorm:"not null" json:"entered_at"`
+}
+
+type GeofenceTrigger string
+
+const (
+	GeofenceEnter GeofenceTrigger = "enter"
+	GeofenceExit  GeofenceTrigger = "exit"
+	GeofenceDwell GeofenceTrigger = "dwell"
+)
+
+var GeofenceTriggers = []GeofenceTrigger{GeofenceEnter, GeofenceExit, GeofenceDwell}
+
+func ValidateGeofenceTrigger(t string) error {
+	for _, trigger := range GeofenceTriggers {
+		if string(trigger) == t {
+			return nil
+		}
+	}
+
+	return errors.New("invalid geofence trigger")
+}
+
+func (t GeofenceTrigger) Value() (driver.Value, error) {
+	return string(t), nil
+}
+
+func (t *GeofenceTrigger) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*t = GeofenceTrigger(v)
+	case []byte:
+		*t = GeofenceTrigger(string(v))
+	default:
+		return fmt.Errorf("unsupported scan type for GeofenceTrigger: %T", value)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DeviceGroup is a named set of devices its owner manages together. Grouping doesn't grant anything,
+// bulk actions only apply to the members the owner is allowed to change.
+type DeviceGroup struct {
+	Base
+	Name        string       `gorm:"not null" json:"name"`
+	Description *string      `json:"description"`
+	OwnerID     uuid.UUID    `gorm:"not null;index" json:"owner_id"`
+	Owner       *User        `json:"-"`
+	Devices     []*GPSDevice `gorm:"many2many:device_group_members" json:"-"`
+	// PollIntervalSeconds and AdaptivePolling apply to the members owned by the owner of the group
+	// that don't set their own, so nobody speeds up polling of devices only shared with them
+	PollIntervalSeconds *uint `json:"poll_interval_seconds"`
+	AdaptivePolling     *bool `json:"adaptive_polling"`
+}
+
+// MemberIDs selects the IDs of the devices in the group, to be used as a subquery
+func (g *DeviceGroup) MemberIDs(db *gorm.DB) *gorm.DB {
+	return db.Table("device_group_members").Select("gps_device_id").Where("device_group_id = ?", g.ID)
+}
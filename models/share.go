@@ -0,0 +1,136 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DeviceRole is what a user may do with a device. The owner, its creator, may do everything and
+// is the only one allowed to share it; managers may change it and send commands, viewers only see it.
+type DeviceRole string
+
+const (
+	DeviceRoleViewer  DeviceRole = "viewer"
+	DeviceRoleManager DeviceRole = "manager"
+	DeviceRoleOwner   DeviceRole = "owner"
+)
+
+// DeviceShareRoles are the roles a device can be shared with, ownership is not shareable
+var DeviceShareRoles = []DeviceRole{DeviceRoleViewer, DeviceRoleManager}
+
+// DeviceShare grants a user, or every member of a community, a role on a device until it expires.
+// Read only members of a community shared with as managers only get to view the device.
+type DeviceShare struct {
+	Base
+	DeviceID    uuid.UUID  `gorm:"not null;uniqueIndex:idx_device_shares_device_user;uniqueIndex:idx_device_shares_device_community" json:"device_id"`
+	Device      *GPSDevice `json:"-"`
+	UserID      *uuid.UUID `gorm:"index;uniqueIndex:idx_device_shares_device_user" json:"user_id"`
+	User        *User      `json:"user,omitempty"`
+	CommunityID *uuid.UUID `gorm:"index;uniqueIndex:idx_device_shares_device_community" json:"community_id"`
+	Community   *Community `json:"-"`
+	Role        DeviceRole `gorm:"type:device_role;not null;default:'viewer'" json:"role"`
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at"`
+	CreatedByID uuid.UUID  `gorm:"not null" json:"created_by_id"`
+	CreatedBy   *User      `json:"-"`
+}
+
+func (r DeviceRole) Value() (driver.Value, error) {
+	return string(r), nil
+}
+
+func (r *DeviceRole) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		*r = DeviceRole(v)
+	case []byte:
+		*r = DeviceRole(string(v))
+	default:
+		return fmt.Errorf("unsupported scan type for DeviceRole: %T", value)
+	}
+	return nil
+}
+
+// rolesAtLeast lists the share roles granting at least role
+func rolesAtLeast(role DeviceRole) []DeviceRole {
+	if role == DeviceRoleViewer {
+		return DeviceShareRoles
+	}
+
+	return []DeviceRole{DeviceRoleManager}
+}
+
+// DevicesWithRole selects the IDs of the devices the user holds at least role on, to be used as a subquery
+func DevicesWithRole(db *gorm.DB, user *User, role DeviceRole) *gorm.DB {
+	if role == DeviceRoleOwner {
+		return db.Model(&GPSDevice{}).Select("id").Where("created_by_id = ?", user.ID)
+	}
+
+	// Read only community members never get more than viewing
+	memberRoles := []MemberRole{ADMIN, READ_ONLY}
+	if role != DeviceRoleViewer {
+		memberRoles = []MemberRole{ADMIN}
+	}
+
+	return db.Raw(`
+		SELECT gps_devices.id FROM gps_devices WHERE gps_devices.created_by_id = @user AND gps_devices.deleted_at IS NULL
+		UNION
+		SELECT device_shares.device_id
+		FROM device_shares
+		WHERE device_shares.user_id = @user AND device_shares.role IN @roles
+			AND (device_shares.expires_at IS NULL OR device_shares.expires_at > NOW()) AND device_shares.deleted_at IS NULL
+		UNION
+		SELECT device_shares.device_id
+		FROM device_shares
+		JOIN communities ON communities.id = device_shares.community_id AND communities.deleted_at IS NULL
+		JOIN community_members ON community_members.community_id = device_shares.community_id
+		WHERE community_members.user_id = @user AND community_members.role IN @member_roles AND device_shares.role IN @roles
+			AND (device_shares.expires_at IS NULL OR device_shares.expires_at > NOW()) AND device_shares.deleted_at IS NULL
+	`, map[string]interface{}{"user": user.ID, "roles": rolesAtLeast(role), "member_roles": memberRoles})
+}
+
+// HasRole reports whether the user holds at least role on the device
+func (d *GPSDevice) HasRole(db *gorm.DB, user *User, role DeviceRole) (bool, error) {
+	if d.CreatedByID != nil && *d.CreatedByID == user.ID {
+		return true, nil
+	}
+
+	if role == DeviceRoleOwner {
+		return false, nil
+	}
+
+	var count int64
+	err := db.Model(&GPSDevice{}).Where("id = ? AND id IN (?)", d.ID, DevicesWithRole(db, user, role)).Count(&count).Error
+
+	return count > 0, err
+}
+
+// Role is the highest role of the user on the device, empty when the user can't see it
+func (d *GPSDevice) Role(db *gorm.DB, user *User) (DeviceRole, error) {
+	for _, role := range []DeviceRole{DeviceRoleOwner, DeviceRoleManager, DeviceRoleViewer} {
+		ok, err := d.HasRole(db, user, role)
+		if err != nil {
+			return "", err
+		}
+
+		if ok {
+			return role, nil
+		}
+	}
+
+	return "", nil
+}
+
+func ValidateDeviceShareRole(role string) error {
+	for _, r := range DeviceShareRoles {
+		if role == string(r) {
+			return nil
+		}
+	}
+
+	return errors.New("invalid device share role")
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeviceDailyStats is how far and how long a device moved during a day, in the timezone of the device.
+// Rollups outlive the locations they are computed from, so reports still cover expired history.
+type DeviceDailyStats struct {
+	DeviceID       uuid.UUID  `gorm:"primaryKey" json:"device_id"`
+	Device         *GPSDevice `json:"-"`
+	Day            time.Time  `gorm:"primaryKey;type:date" json:"day"`
+	DistanceMeters float64    `gorm:"not null" json:"distance_meters"`
+	MovingSeconds  int64      `gorm:"not null" json:"moving_seconds"`
+	IdleSeconds    int64      `gorm:"not null" json:"idle_seconds"`
+	MaxSpeed       float64    `gorm:"not null" json:"max_speed"`
+}
+
+// DeviceStatsRollup remembers up to which insertion time the locations of a device are rolled up. Insertion
+// time rather than fix time, so delayed and imported points still update the days they belong to.
+type DeviceStatsRollup struct {
+	DeviceID       uuid.UUID  `gorm:"primaryKey" json:"device_id"`
+	Device         *GPSDevice `json:"-"`
+	ProcessedUntil time.Time  `gorm:"not null" json:"processed_until"`
+}
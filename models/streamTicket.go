@@ -0,0 +1,73 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Tickets only have to survive the round trip between requesting one and opening the stream
+const StreamTicketTTL = 30 * time.Second
+
+var ErrInvalidStreamTicket = errors.New("invalid or expired ticket")
+
+// StreamTicket lets clients unable to set headers open a stream without putting their JWT in the URL,
+// where access logs and proxies would keep it. Tickets are short lived, redeemed once and stored hashed.
+type StreamTicket struct {
+	TokenHash string    `gorm:"primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null"`
+	User      *User
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+func hashStreamTicket(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueStreamTicket returns a new ticket for the user, only its hash is stored
+func IssueStreamTicket(db *gorm.DB, user *User) (string, time.Time, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, err
+	}
+
+	token := hex.EncodeToString(b)
+	expiresAt := time.Now().UTC().Add(StreamTicketTTL)
+
+	// Expired tickets are never redeemed, they are dropped as new ones are issued
+	if err := db.Where("expires_at < ?", time.Now().UTC()).Delete(&StreamTicket{}).Error; err != nil {
+		return "", time.Time{}, err
+	}
+
+	if err := db.Create(&StreamTicket{TokenHash: hashStreamTicket(token), UserID: user.ID, ExpiresAt: expiresAt}).Error; err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// RedeemStreamTicket consumes the ticket and returns its user
+func RedeemStreamTicket(db *gorm.DB, token string) (*User, error) {
+	var tickets []StreamTicket
+	if err := db.Raw("DELETE FROM stream_tickets WHERE token_hash = ? AND expires_at > ? RETURNING *", hashStreamTicket(token), time.Now().UTC()).
+		Scan(&tickets).Error; err != nil {
+		return nil, err
+	}
+
+	if len(tickets) == 0 {
+		return nil, ErrInvalidStreamTicket
+	}
+
+	var user User
+	if err := db.First(&user, "id = ?", tickets[0].UserID).Error; err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
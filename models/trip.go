@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Trip struct {
+	Base
+	DeviceID        uuid.UUID  `gorm:"not null;index:idx_trips_device_start_time,priority:1" json:"device_id"`
+	Device          *GPSDevice `json:"-"`
+	StartTime       time.Time  `gorm:"not null;index:idx_trips_device_start_time,priority:2" json:"start_time"`
+	EndTime         time.Time  `gorm:"not null" json:"end_time"`
+	StartLatitude   float64    `gorm:"not null" json:"start_latitude"`
+	StartLongitude  float64    `gorm:"not null" json:"start_longitude"`
+	EndLatitude     float64    `gorm:"not null" json:"end_latitude"`
+	EndLongitude    float64    `gorm:"not null" json:"end_longitude"`
+	DistanceMeters  float64    `gorm:"not null" json:"distance_meters"`
+	DurationSeconds int64      `gorm:"not null" json:"duration_seconds"`
+	MaxSpeed        float64    `gorm:"not null" json:"max_speed"`
+	AvgSpeed        float64    `gorm:"not null" json:"avg_speed"`
+}
+
+type Stop struct {
+	Base
+	DeviceID        uuid.UUID  `gorm:"not null;index:idx_stops_device_start_time,priority:1" json:"device_id"`
+	Device          *GPSDevice `json:"-"`
+	StartTime       time.Time  `gorm:"not null;index:idx_stops_device_start_time,priority:2" json:"start_time"`
+	EndTime         time.Time  `gorm:"not null" json:"end_time"`
+	Latitude        float64    `gorm:"not null" json:"latitude"`
+	Longitude       float64    `gorm:"not null" json:"longitude"`
+	DurationSeconds int64      `gorm:"not null" json:"duration_seconds"`
+}
+
+// TripSegmentation remembers up to which fix time the history of a device has been split into trips and stops.
+type TripSegmentation struct {
+	DeviceID       uuid.UUID  `gorm:"primaryKey" json:"device_id"`
+	Device         *GPSDevice `json:"-"`
+	ProcessedUntil time.Time  `gorm:"not null" json:"processed_until"`
+}
+
+// InvalidateTripsSince drops trips and stops touching history from since onwards, so locations
+// inserted out of order are segmented again by the worker.
+func InvalidateTripsSince(db *gorm.DB, deviceID uuid.UUID, since time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var earliest *time.Time
+		if err := tx.Raw(`
+			SELECT MIN(start_time) FROM (
+				SELECT start_time FROM trips WHERE device_id = ? AND end_time >= ?
+				UNION ALL
+				SELECT start_time FROM stops WHERE device_id = ? AND end_time >= ?
+			) segments`, deviceID, since, deviceID, since).Scan(&earliest).Error; err != nil {
+			return err
+		}
+
+		resetTo := since
+		if earliest != nil && earliest.Before(since) {
+			resetTo = *earliest
+		}
+
+		if err := tx.Unscoped().Where("device_id = ? AND end_time >= ?", deviceID, since).Delete(&Trip{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("device_id = ? AND end_time >= ?", deviceID, since).Delete(&Stop{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&TripSegmentation{}).Where("device_id = ? AND processed_until > ?", deviceID, resetTo).Update("processed_until", resetTo).Error
+	})
+}
@@ -27,12 +27,62 @@ type UserSettings struct {
 
 type Notification struct {
 	Base
-	Message string    `gorm:"not null" json:"message"`
-	User    *User     `json:"-"`
-	UserID  uuid.UUID `gorm:"not null;index" json:"user_id"`
-	Event   *Event    `json:"-"`
-	EventID uuid.UUID `gorm:"not null;index" json:"event_id"`
-	IsRead  bool      `gorm:"not null;default:false"`
+	Message              string              `gorm:"not null" json:"message"`
+	User                 *User               `json:"-"`
+	UserID               uuid.UUID           `gorm:"not null;index" json:"user_id"`
+	Event                *Event              `json:"-"`
+	EventID              *uuid.UUID          `gorm:"index" json:"event_id"`
+	GeofenceTransition   *GeofenceTransition `json:"-"`
+	GeofenceTransitionID *uuid.UUID          `gorm:"index" json:"geofence_transition_id"`
+	Device               *GPSDevice          `json:"-"`
+	DeviceID             *uuid.UUID          `gorm:"index" json:"device_id"`
+	IsRead               bool                `gorm:"not null;default:false"`
+}
+
+// NotifyDeviceTrackers sends a copy of the notification to every user tracking the device,
+// personally through their settings or as a member of a community tracking it.
+func NotifyDeviceTrackers(db *gorm.DB, deviceID uuid.UUID, notification Notification) error {
+	var userIDs []uuid.UUID
+	if err := db.Raw(`
+		SELECT user_settings.user_id
+		FROM user_tracking
+		JOIN user_settings ON user_settings.id = user_tracking.user_settings_id AND user_settings.deleted_at IS NULL
+		WHERE user_tracking.gps_device_id = ?
+		UNION
+		SELECT community_members.user_id
+		FROM community_tracking
+		JOIN communities ON communities.id = community_tracking.community_id AND communities.deleted_at IS NULL
+		JOIN community_members ON community_members.community_id = community_tracking.community_id
+		WHERE community_tracking.gps_device_id = ?
+	`, deviceID, deviceID).Scan(&userIDs).Error; err != nil {
+		return err
+	}
+
+	// Tracking outlives shares, only users the device is still shared with are notified
+	var device GPSDevice
+	if err := db.Select("id", "created_by_id").First(&device, "id = ?", deviceID).Error; err != nil {
+		return err
+	}
+
+	var notifications []Notification
+	for _, userID := range userIDs {
+		canView, err := device.HasRole(db, &User{Base: Base{ID: userID}}, DeviceRoleViewer)
+		if err != nil {
+			return err
+		}
+
+		if canView {
+			recipient := notification
+			recipient.UserID = userID
+			notifications = append(notifications, recipient)
+		}
+	}
+
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	return db.Create(&notifications).Error
 }
 
 func GetUserSettings(db *gorm.DB, user *User) (*UserSettings, error) {
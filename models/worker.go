@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WorkerReplica is a running worker process, rows without a recent heartbeat belong to dead replicas.
+type WorkerReplica struct {
+	ID          string    `gorm:"primaryKey" json:"id"`
+	HeartbeatAt time.Time `gorm:"not null;index" json:"heartbeat_at"`
+}
+
+// DeviceLease prevents two replicas from polling the same device while their views of the cluster differ.
+// Devices polled through a shared provider account are leased by the account ID.
+type DeviceLease struct {
+	DeviceID  uuid.UUID `gorm:"primaryKey" json:"device_id"`
+	WorkerID  string    `gorm:"not null" json:"worker_id"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+}
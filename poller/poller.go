@@ -0,0 +1,311 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/config"
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Targets are reloaded this often, which is also how quickly new devices and interval changes are picked up
+const reloadInterval = 30 * time.Second
+
+// Target is polled as a unit, all its devices share a single provider session
+type Target struct {
+	// ID is the provider account, or the device for devices with their own credentials
+	ID      uuid.UUID
+	Account *models.ProviderAccount
+	Devices []*models.GPSDevice
+	// Interval is the time between two polls of the target
+	Interval time.Duration
+}
+
+// PollFunc fetches and stores the positions of the devices of a target
+type PollFunc func(ctx context.Context, db *gorm.DB, target *Target) error
+
+// TargetLoader lists the targets to poll with their current interval
+type TargetLoader func(db *gorm.DB) ([]Target, error)
+
+// Coordinator spreads targets over worker replicas
+type Coordinator interface {
+	// Owns reports whether the target is polled by this replica
+	Owns(id uuid.UUID) bool
+	// Claim leases the target for ttl, false means another replica polled it recently
+	Claim(id uuid.UUID, ttl time.Duration) (bool, error)
+}
+
+// Poller polls every target when it is due with a bounded number of workers. Targets that keep failing
+// are retried with exponential backoff and, past a threshold, skipped entirely until a cooldown expires.
+type Poller struct {
+	db          *gorm.DB
+	coordinator Coordinator
+	targets     TargetLoader
+	poll        PollFunc
+
+	mu     sync.Mutex
+	states map[uuid.UUID]*targetState
+	// wake interrupts the wait of the dispatcher when a poll ends, targets being polled don't count toward
+	// the next wake up so their next poll is only known then
+	wake chan struct{}
+}
+
+type targetState struct {
+	failures int
+	retryAt  time.Time
+	polledAt time.Time
+	// spread shifts every poll by up to ±10% of the interval so replicas and devices don't hit the provider in lockstep
+	spread  float64
+	polling bool
+}
+
+func New(db *gorm.DB, coordinator Coordinator, targets TargetLoader, poll PollFunc) *Poller {
+	return &Poller{db: db, coordinator: coordinator, targets: targets, poll: poll, states: map[uuid.UUID]*targetState{}, wake: make(chan struct{}, 1)}
+}
+
+// Run polls until ctx is cancelled. In-flight polls are allowed to finish so their writes are not cut.
+func (p *Poller) Run(ctx context.Context) {
+	log.Default().Println("Location server started")
+
+	conf := config.GetConfig(p.db)
+
+	workers := int(conf.PollWorkers)
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan *Target)
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for target := range jobs {
+				p.pollTarget(target, conf)
+			}
+		}()
+	}
+
+	var targets []Target
+	var loadedAt time.Time
+
+	for {
+		if time.Since(loadedAt) >= reloadInterval {
+			loaded, err := p.targets(p.db)
+			if err != nil {
+				log.Default().Println("Failed to load devices to poll", err)
+			} else {
+				targets = loaded
+				p.forget(targets)
+			}
+			loadedAt = time.Now()
+		}
+
+		next := loadedAt.Add(reloadInterval)
+
+	dispatch:
+		for i := range targets {
+			target := &targets[i]
+			if !p.coordinator.Owns(target.ID) {
+				continue
+			}
+
+			due, polling := p.due(target)
+			if polling {
+				continue
+			}
+
+			if due.After(time.Now()) {
+				if due.Before(next) {
+					next = due
+				}
+				continue
+			}
+
+			p.setPolling(target.ID)
+
+			select {
+			case jobs <- target:
+			case <-ctx.Done():
+				p.release(target.ID, time.Time{})
+				break dispatch
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			close(jobs)
+			wg.Wait()
+			log.Default().Println("Location server stopped")
+			return
+		case <-p.wake:
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
+
+func (p *Poller) pollTarget(target *Target, conf *models.Config) {
+	started := time.Now()
+
+	// Replicas may briefly disagree on ownership while one joins or dies
+	claimed, err := p.coordinator.Claim(target.ID, target.Interval/2)
+	if err != nil || !claimed {
+		p.release(target.ID, started)
+		return
+	}
+
+	// Not derived from the shutdown context, a poll that started is allowed to complete
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(conf.PollTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	err = p.safePoll(ctx, target)
+	if err != nil {
+		log.Default().Println("Failed to receive device location", target.ID, err)
+	}
+
+	p.record(target, started, err, conf)
+}
+
+// A misbehaving provider must only fail its target, not take the whole worker down
+func (p *Poller) safePoll(ctx context.Context, target *Target) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("poll panicked: %v", r)
+		}
+	}()
+
+	return p.poll(ctx, p.db, target)
+}
+
+// due is when the target should be polled next, targets never polled are due right away.
+// The interval is applied on every call so a target switching to a shorter interval is polled sooner.
+func (p *Poller) due(target *Target) (time.Time, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, ok := p.states[target.ID]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	due := state.retryAt
+	if !state.polledAt.IsZero() {
+		next := state.polledAt.Add(target.Interval + time.Duration(float64(target.Interval)*state.spread))
+		if next.After(due) {
+			due = next
+		}
+	}
+
+	return due, state.polling
+}
+
+// state returns the state of the target, creating it. p.mu must be held.
+func (p *Poller) state(id uuid.UUID) *targetState {
+	state, ok := p.states[id]
+	if !ok {
+		state = &targetState{}
+		p.states[id] = state
+	}
+
+	return state
+}
+
+func (p *Poller) setPolling(id uuid.UUID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.state(id).polling = true
+}
+
+// release ends a poll that didn't reach the provider, a zero polledAt leaves the target due
+func (p *Poller) release(id uuid.UUID, polledAt time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state := p.state(id)
+	state.polling = false
+
+	if !polledAt.IsZero() {
+		state.polledAt = polledAt
+	}
+
+	p.notify()
+}
+
+// record schedules the next poll of a target after polling it
+func (p *Poller) record(target *Target, polledAt time.Time, err error, conf *models.Config) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := target.ID
+	state := p.state(id)
+	state.polling = false
+	state.polledAt = polledAt
+	state.spread = (rand.Float64() - 0.5) / 5
+
+	defer p.notify()
+
+	if err == nil {
+		state.failures = 0
+		state.retryAt = time.Time{}
+		return
+	}
+
+	state.failures++
+
+	if state.failures >= int(conf.PollCircuitFailures) {
+		if state.failures == int(conf.PollCircuitFailures) {
+			log.Default().Println("Target", id, "keeps failing, pausing polls for", conf.PollCircuitCooldownMinutes, "minutes")
+		}
+
+		state.retryAt = time.Now().Add(time.Duration(conf.PollCircuitCooldownMinutes) * time.Minute)
+		return
+	}
+
+	delay := target.Interval << (state.failures - 1)
+	if maxDelay := time.Duration(conf.PollBackoffMaxMinutes) * time.Minute; delay > maxDelay {
+		delay = maxDelay
+	}
+
+	state.retryAt = time.Now().Add(delay/2 + randomDuration(delay/2))
+}
+
+// notify wakes the dispatcher up, a pending wake up already covers this one
+func (p *Poller) notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// forget drops the state of targets that are no longer polled
+func (p *Poller) forget(targets []Target) {
+	polled := make(map[uuid.UUID]bool, len(targets))
+	for _, target := range targets {
+		polled[target.ID] = true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for id, state := range p.states {
+		if !polled[id] && !state.polling {
+			delete(p.states, id)
+		}
+	}
+}
+
+func randomDuration(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(max)))
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// pollSettings is what a device inherits from its groups
+type pollSettings struct {
+	interval *uint
+	adaptive *bool
+}
+
+// PollIntervals resolves how often each device is polled. The device setting wins over its groups, where the
+// most frequent interval wins, and the groups over the config. Adaptive devices are polled faster while
+// moving or involved in an open event, and slower while stationary or offline.
+func PollIntervals(db *gorm.DB, conf *models.Config, devices []*models.GPSDevice) (map[uuid.UUID]time.Duration, error) {
+	ids := make([]uuid.UUID, len(devices))
+	for i, device := range devices {
+		ids[i] = device.ID
+	}
+
+	groups, err := groupPollSettings(db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := activeDevices(db, conf, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	intervals := make(map[uuid.UUID]time.Duration, len(devices))
+	for _, device := range devices {
+		base := conf.PollInterval
+		adaptive := conf.AdaptivePolling
+
+		if group, ok := groups[device.ID]; ok {
+			if group.interval != nil {
+				base = *group.interval
+			}
+
+			if group.adaptive != nil {
+				adaptive = *group.adaptive
+			}
+		}
+
+		if device.PollIntervalSeconds != nil {
+			base = *device.PollIntervalSeconds
+		}
+
+		if device.AdaptivePolling != nil {
+			adaptive = *device.AdaptivePolling
+		}
+
+		seconds := base
+		if adaptive {
+			switch {
+			case active[device.ID]:
+				seconds = min(base, conf.PollMovingSeconds)
+			case device.Status == models.DeviceStatusOffline:
+				seconds = max(base, conf.PollOfflineSeconds)
+			default:
+				seconds = max(base, conf.PollStationarySeconds)
+			}
+		}
+
+		intervals[device.ID] = time.Duration(max(seconds, models.MinPollIntervalSeconds)) * time.Second
+	}
+
+	return intervals, nil
+}
+
+// groupPollSettings merges the settings of the groups of each device. Only groups of the owner of the
+// device count, and a group asking for adaptive polling wins over one turning it off.
+func groupPollSettings(db *gorm.DB, ids []uuid.UUID) (map[uuid.UUID]pollSettings, error) {
+	var rows []struct {
+		DeviceID            uuid.UUID
+		PollIntervalSeconds *uint
+		AdaptivePolling     *bool
+	}
+
+	if err := db.Raw(`
+		SELECT device_group_members.gps_device_id AS device_id, device_groups.poll_interval_seconds, device_groups.adaptive_polling
+		FROM device_group_members
+		JOIN device_groups ON device_groups.id = device_group_members.device_group_id AND device_groups.deleted_at IS NULL
+		JOIN gps_devices ON gps_devices.id = device_group_members.gps_device_id AND gps_devices.created_by_id = device_groups.owner_id
+		WHERE device_group_members.gps_device_id IN ?
+			AND (device_groups.poll_interval_seconds IS NOT NULL OR device_groups.adaptive_polling IS NOT NULL)
+	`, ids).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	settings := map[uuid.UUID]pollSettings{}
+	for _, row := range rows {
+		merged := settings[row.DeviceID]
+
+		if row.PollIntervalSeconds != nil && (merged.interval == nil || *row.PollIntervalSeconds < *merged.interval) {
+			merged.interval = row.PollIntervalSeconds
+		}
+
+		if row.AdaptivePolling != nil && (merged.adaptive == nil || *row.AdaptivePolling) {
+			merged.adaptive = row.AdaptivePolling
+		}
+
+		settings[row.DeviceID] = merged
+	}
+
+	return settings, nil
+}
+
+// activeDevices finds the devices involved in an open event or that moved lately, either reporting a speed
+// or leaving the stop radius within the minimum duration of a stop
+func activeDevices(db *gorm.DB, conf *models.Config, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	active := map[uuid.UUID]bool{}
+
+	var withEvents []uuid.UUID
+	if err := db.Model(&models.Event{}).Where("device_id IN ? AND status = ?", ids, models.EventStatusOpen).
+		Distinct().Pluck("device_id", &withEvents).Error; err != nil {
+		return nil, err
+	}
+
+	for _, id := range withEvents {
+		active[id] = true
+	}
+
+	var moving []uuid.UUID
+	if err := db.Raw(`
+		SELECT device_id FROM gps_locations
+		WHERE device_id IN @ids AND filter_reason IS NULL AND deleted_at IS NULL AND fix_time > @since
+		GROUP BY device_id
+		HAVING MAX(speed) >= @speed
+			OR ST_Distance(
+				ST_MakePoint(MIN(longitude), MIN(latitude))::geography,
+				ST_MakePoint(MAX(longitude), MAX(latitude))::geography
+			) > @radius
+	`, map[string]interface{}{
+		"ids":    ids,
+		"since":  time.Now().UTC().Add(-time.Duration(conf.StopMinMinutes) * time.Minute),
+		"speed":  conf.MovingSpeedKmh,
+		"radius": conf.StopRadiusMeters,
+	}).Scan(&moving).Error; err != nil {
+		return nil, err
+	}
+
+	for _, id := range moving {
+		active[id] = true
+	}
+
+	return active, nil
+}
@@ -0,0 +1,295 @@
+package providers
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/google/uuid"
+)
+
+const DefaultGPS365BaseURL = "https://www.365gps.net"
+
+const DefaultGPS365Timeout = 15 * time.Second
+
+// Responses are tiny, anything bigger is not the page we asked for
+const maxGPS365ResponseSize = 1 << 20
+
+// GPS365Marker is one tracker of the account as listed on the map
+type GPS365Marker struct {
+	Imei      string `json:"imei"`
+	Lat       string `json:"lat"`
+	Lng       string `json:"lng"`
+	Gpstime   string `json:"gpstime"`
+	LatGoogle string `json:"lat_google"`
+	LngGoogle string `json:"lng_google"`
+	Speed     string `json:"speed"`
+	Course    string `json:"course"`
+}
+
+type LocationResponse struct {
+	AaData []GPS365Marker `json:"aaData"`
+}
+
+// GPS365 talks to the 365gps.net web UI. BaseURL can point to a local fake server.
+type GPS365 struct {
+	BaseURL string
+	client  *http.Client
+}
+
+type GPS365Options struct {
+	Timeout time.Duration
+	// InsecureSkipVerify disables TLS certificate checks, only for upstreams with a broken chain
+	InsecureSkipVerify bool
+}
+
+func NewGPS365(baseURL string, options GPS365Options) *GPS365 {
+	if options.Timeout == 0 {
+		options.Timeout = DefaultGPS365Timeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if options.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &GPS365{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Transport: transport, Timeout: options.Timeout},
+	}
+}
+
+func (p *GPS365) Login(ctx context.Context, credentials Credentials) (string, error) {
+	cookie, err := p.createSessionCookie(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if err := p.login(ctx, cookie, credentials.Username, credentials.Password); err != nil {
+		return "", err
+	}
+
+	// The login page answers the same way whether credentials are right or not, a session that can't
+	// list markers right away was never authenticated
+	if _, err := p.getMarkers(ctx, cookie); err != nil {
+		if errors.Is(err, ErrSessionExpired) {
+			return "", ErrLoginFailed
+		}
+		return "", err
+	}
+
+	return cookie, nil
+}
+
+// FetchPositions lists every tracker of the account with a single request and matches them to the devices by IMEI
+func (p *GPS365) FetchPositions(ctx context.Context, session string, devices []*models.GPSDevice) (map[uuid.UUID][]Position, error) {
+	markers, err := p.getMarkers(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	byImei := make(map[string]*GPS365Marker, len(markers))
+	for i := range markers {
+		byImei[strings.TrimSpace(markers[i].Imei)] = &markers[i]
+	}
+
+	positions := make(map[uuid.UUID][]Position, len(devices))
+	for _, device := range devices {
+		marker := byImei[*device.Imei]
+
+		// Accounts logged into with the IMEI only ever list that tracker
+		if marker == nil && len(devices) == 1 && len(markers) == 1 {
+			marker = &markers[0]
+		}
+
+		if marker == nil {
+			continue
+		}
+
+		// A tracker without a fix yet must not cost the other trackers of the account their positions
+		position, err := marker.toPosition()
+		if err != nil {
+			log.Default().Println("Skipping tracker", *device.Imei, "of device", device.ID, err)
+			continue
+		}
+
+		positions[device.ID] = []Position{*position}
+	}
+
+	return positions, nil
+}
+
+func (p *GPS365) SendCommand(ctx context.Context, session string, device *models.GPSDevice, command string) error {
+	switch command {
+	case RefreshLocationCommand:
+		return p.refreshLocation(ctx, session, *device.Imei)
+	default:
+		return ErrUnsupportedCommand
+	}
+}
+
+// do sends the request and returns the body without BOM, transport failures and server errors
+// are reported as ErrUpstreamUnavailable so callers can retry later.
+func (p *GPS365) do(req *http.Request) (*http.Response, []byte, error) {
+	req.Header.Add("Accept-Language", "en-GB,en-US;q=0.9,en;q=0.8")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrUpstreamUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, maxGPS365ResponseSize))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrUpstreamUnavailable, err)
+	}
+
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return nil, nil, fmt.Errorf("%w: status %d", ErrUpstreamUnavailable, resp.StatusCode)
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, nil, fmt.Errorf("%w: status %d", ErrMalformedPayload, resp.StatusCode)
+	}
+
+	// Check for BOM and strip it if present
+	if len(bodyBytes) >= 3 && bodyBytes[0] == 0xEF && bodyBytes[1] == 0xBB && bodyBytes[2] == 0xBF {
+		bodyBytes = bodyBytes[3:]
+	}
+
+	return resp, bodyBytes, nil
+}
+
+func (p *GPS365) postForm(ctx context.Context, path string, cookie string, form url.Values) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", p.BaseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Cookie", cookie)
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	_, body, err := p.do(req)
+	return body, err
+}
+
+func (p *GPS365) createSessionCookie(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.BaseURL+"/login.php", nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, _, err := p.do(req)
+	if err != nil {
+		return "", err
+	}
+
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "PHPSESSID" {
+			return cookie.Name + "=" + cookie.Value, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: no PHPSESSID cookie found", ErrMalformedPayload)
+}
+
+func (p *GPS365) login(ctx context.Context, cookie string, username string, password string) error {
+	form := url.Values{}
+	form.Add("demo", "F")
+	form.Add("username", username)
+	form.Add("password", password)
+	form.Add("form_type", "0")
+
+	_, err := p.postForm(ctx, "/npost_login.php?lang=en", cookie, form)
+	return err
+}
+
+func (p *GPS365) refreshLocation(ctx context.Context, cookie string, imei string) error {
+	form := url.Values{}
+	form.Add("imei", imei)
+
+	body, err := p.postForm(ctx, "/post_submit_sendloc.php", cookie, form)
+	if err != nil {
+		return err
+	}
+
+	if respString := strings.TrimSpace(string(body)); respString != "Y" {
+		return fmt.Errorf("refresh location rejected: %s", respString)
+	}
+
+	return nil
+}
+
+// getMarkers asks for UTC times, timezonemins follows the JS getTimezoneOffset convention.
+func (p *GPS365) getMarkers(ctx context.Context, cookie string) ([]GPS365Marker, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.BaseURL+"/post_map_marker_list.php?timezonemins=0", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Cookie", cookie)
+
+	_, bodyBytes, err := p.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if string(bodyBytes) == "{\"result\":\"NULL\"}" {
+		return nil, ErrSessionExpired
+	}
+
+	var responseJson LocationResponse
+	if err := json.Unmarshal(bodyBytes, &responseJson); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedPayload, err)
+	}
+
+	if len(responseJson.AaData) == 0 {
+		return nil, ErrDeviceNotFound
+	}
+
+	return responseJson.AaData, nil
+}
+
+func (m *GPS365Marker) toPosition() (*Position, error) {
+	latitude, err := strconv.ParseFloat(m.LatGoogle, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: latitude %q", ErrMalformedPayload, m.LatGoogle)
+	}
+
+	longitude, err := strconv.ParseFloat(m.LngGoogle, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: longitude %q", ErrMalformedPayload, m.LngGoogle)
+	}
+
+	position := &Position{
+		Latitude:  latitude,
+		Longitude: longitude,
+		Speed:     parseOptionalFloat(m.Speed),
+		Course:    parseOptionalFloat(m.Course),
+	}
+
+	// A fix without a readable time is still worth storing, it is stamped on arrival
+	if fixTime, err := time.ParseInLocation("2006-01-02 15:04:05", m.Gpstime, time.UTC); err == nil {
+		position.FixTime = fixTime
+	}
+
+	return position, nil
+}
+
+func parseOptionalFloat(value string) *float64 {
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
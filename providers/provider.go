@@ -0,0 +1,97 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/google/uuid"
+)
+
+// Position is a single fix as reported by a provider. Optional values are nil when unknown.
+type Position struct {
+	Latitude   float64
+	Longitude  float64
+	FixTime    time.Time
+	Speed      *float64
+	Course     *float64
+	Altitude   *float64
+	Satellites *int
+	Accuracy   *float64
+	Battery    *float64
+	Signal     *float64
+}
+
+func (p *Position) ToGPSLocation() *models.GPSLocation {
+	return &models.GPSLocation{
+		Latitude:   p.Latitude,
+		Longitude:  p.Longitude,
+		FixTime:    p.FixTime,
+		Speed:      p.Speed,
+		Course:     p.Course,
+		Altitude:   p.Altitude,
+		Satellites: p.Satellites,
+		Accuracy:   p.Accuracy,
+		Battery:    p.Battery,
+		Signal:     p.Signal,
+	}
+}
+
+// Credentials log into a vendor account, which may own a single tracker or many.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// DeviceProvider is implemented by every tracker vendor the worker can poll.
+// Implementations must give up once ctx is done.
+type DeviceProvider interface {
+	// Login opens a new session for the account and returns the session token.
+	Login(ctx context.Context, credentials Credentials) (string, error)
+	// FetchPositions returns the latest known positions of the devices reachable with the session,
+	// oldest first and keyed by device ID. Devices unknown to the account or without a readable position
+	// are missing from the map, a single device never fails the whole account.
+	FetchPositions(ctx context.Context, session string, devices []*models.GPSDevice) (map[uuid.UUID][]Position, error)
+	// SendCommand asks the vendor to deliver a command to the device.
+	SendCommand(ctx context.Context, session string, device *models.GPSDevice, command string) error
+}
+
+const (
+	RefreshLocationCommand string = "refresh_location"
+)
+
+var (
+	ErrSessionExpired      = errors.New("provider session expired")
+	ErrUnsupportedCommand  = errors.New("command is not supported by provider")
+	ErrNoPositions         = errors.New("provider returned no positions")
+	ErrUnknownProviderType = errors.New("unknown device provider")
+	ErrLoginFailed         = errors.New("provider rejected the credentials")
+	ErrDeviceNotFound      = errors.New("device not found at provider")
+	ErrUpstreamUnavailable = errors.New("provider is unavailable")
+	ErrMalformedPayload    = errors.New("provider returned a malformed response")
+)
+
+var registry = map[models.DeviceProviderType]DeviceProvider{}
+
+func Register(providerType models.DeviceProviderType, provider DeviceProvider) {
+	registry[providerType] = provider
+}
+
+func Get(providerType models.DeviceProviderType) (DeviceProvider, error) {
+	provider, ok := registry[providerType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProviderType, providerType)
+	}
+	return provider, nil
+}
+
+// Types lists the provider types that can be polled by the worker.
+func Types() []models.DeviceProviderType {
+	types := make([]models.DeviceProviderType, 0, len(registry))
+	for providerType := range registry {
+		types = append(types, providerType)
+	}
+	return types
+}
@@ -0,0 +1,34 @@
+package providers
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+)
+
+func Setup() {
+	gps365BaseURL := os.Getenv("GPS365_BASE_URL")
+
+	if gps365BaseURL == "" {
+		gps365BaseURL = DefaultGPS365BaseURL
+	}
+
+	options := GPS365Options{InsecureSkipVerify: os.Getenv("GPS365_INSECURE_SKIP_VERIFY") == "true"}
+
+	if timeout := os.Getenv("GPS365_TIMEOUT_SECONDS"); timeout != "" {
+		seconds, err := strconv.Atoi(timeout)
+		if err != nil {
+			panic("GPS365_TIMEOUT_SECONDS must be a number")
+		}
+		options.Timeout = time.Duration(seconds) * time.Second
+	}
+
+	if options.InsecureSkipVerify {
+		log.Println("TLS verification of 365gps is disabled")
+	}
+
+	Register(models.Provider365GPS, NewGPS365(gps365BaseURL, options))
+}
@@ -0,0 +1,151 @@
+package realtime
+
+import (
+	"errors"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BoundingBox struct {
+	MinLongitude float64
+	MinLatitude  float64
+	MaxLongitude float64
+	MaxLatitude  float64
+}
+
+func (b *BoundingBox) Contains(latitude, longitude float64) bool {
+	return latitude >= b.MinLatitude && latitude <= b.MaxLatitude && longitude >= b.MinLongitude && longitude <= b.MaxLongitude
+}
+
+// Filter decides which messages a stream receives. It is resolved once when the stream opens,
+// tracking or membership changes apply to streams opened afterwards.
+type Filter struct {
+	userID uuid.UUID
+	// devices are the subscribed devices, visibleDevices all devices the user may follow
+	devices        map[uuid.UUID]bool
+	visibleDevices map[uuid.UUID]bool
+	// communities are the subscribed communities, memberOf all communities of the user
+	communities map[uuid.UUID]bool
+	memberOf    map[uuid.UUID]bool
+	bbox        *BoundingBox
+}
+
+var ErrNotTracked = errors.New("device is not shared with the user")
+var ErrNotMember = errors.New("user is not a member of the community")
+
+// NewFilter subscribes the user to the given devices, communities and area. With none of them
+// the stream follows every device the user can see and every community they belong to.
+func NewFilter(db *gorm.DB, user *models.User, deviceIDs []uuid.UUID, communityIDs []uuid.UUID, bbox *BoundingBox) (*Filter, error) {
+	var visibleDevices []uuid.UUID
+	if err := db.Model(&models.GPSDevice{}).Where("id IN (?)", models.DevicesWithRole(db, user, models.DeviceRoleViewer)).
+		Pluck("id", &visibleDevices).Error; err != nil {
+		return nil, err
+	}
+
+	var memberOf []uuid.UUID
+	if err := db.Model(&models.CommunityMember{}).Where("user_id = ?", user.ID).Pluck("community_id", &memberOf).Error; err != nil {
+		return nil, err
+	}
+
+	f := &Filter{
+		userID:         user.ID,
+		devices:        map[uuid.UUID]bool{},
+		visibleDevices: toSet(visibleDevices),
+		communities:    map[uuid.UUID]bool{},
+		memberOf:       toSet(memberOf),
+		bbox:           bbox,
+	}
+
+	if len(deviceIDs) == 0 && len(communityIDs) == 0 && bbox == nil {
+		f.devices = f.visibleDevices
+		f.communities = f.memberOf
+		return f, nil
+	}
+
+	for _, id := range deviceIDs {
+		if !f.visibleDevices[id] {
+			return nil, ErrNotTracked
+		}
+		f.devices[id] = true
+	}
+
+	for _, id := range communityIDs {
+		if !f.memberOf[id] {
+			return nil, ErrNotMember
+		}
+		f.communities[id] = true
+	}
+
+	if len(communityIDs) > 0 {
+		// A community subscription includes the positions of the devices the community tracks
+		var communityDevices []uuid.UUID
+		if err := db.Table("community_tracking").Where("community_id IN ?", communityIDs).Pluck("gps_device_id", &communityDevices).Error; err != nil {
+			return nil, err
+		}
+
+		for _, id := range communityDevices {
+			f.devices[id] = true
+		}
+	}
+
+	return f, nil
+}
+
+func (f *Filter) matches(n *notification) bool {
+	switch n.Type {
+	case MessageLocation, MessageDeviceStatus:
+		if n.DeviceID == nil || !f.visibleDevices[*n.DeviceID] {
+			return false
+		}
+
+		return f.devices[*n.DeviceID] || f.inBoundingBox(n)
+
+	case MessageEvent, MessageComment:
+		if !f.canSeeEvent(n) {
+			return false
+		}
+
+		if n.DeviceID != nil && f.devices[*n.DeviceID] {
+			return true
+		}
+
+		for _, id := range n.CommunityIDs {
+			if f.communities[id] {
+				return true
+			}
+		}
+
+		return f.inBoundingBox(n)
+	}
+
+	return false
+}
+
+// canSeeEvent mirrors models.Event.HasAccess for readers
+func (f *Filter) canSeeEvent(n *notification) bool {
+	if n.Public || (n.CreatedByID != nil && *n.CreatedByID == f.userID) {
+		return true
+	}
+
+	for _, id := range n.CommunityIDs {
+		if f.memberOf[id] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *Filter) inBoundingBox(n *notification) bool {
+	return f.bbox != nil && n.Latitude != nil && n.Longitude != nil && f.bbox.Contains(*n.Latitude, *n.Longitude)
+}
+
+func toSet(ids []uuid.UUID) map[uuid.UUID]bool {
+	set := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
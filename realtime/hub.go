@@ -0,0 +1,126 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Messages buffered per stream, a client falling further behind is disconnected and has to reconnect
+const subscriptionBuffer = 64
+
+const maxReconnectDelay = 30 * time.Second
+
+type Subscription struct {
+	Messages chan Message
+	filter   *Filter
+}
+
+type hub struct {
+	mu            sync.Mutex
+	subscriptions map[*Subscription]bool
+}
+
+var defaultHub = &hub{subscriptions: map[*Subscription]bool{}}
+
+// Subscribe opens a stream of the messages matching filter, Messages is closed when the stream
+// is unsubscribed or can't keep up.
+func Subscribe(filter *Filter) *Subscription {
+	s := &Subscription{Messages: make(chan Message, subscriptionBuffer), filter: filter}
+
+	defaultHub.mu.Lock()
+	defaultHub.subscriptions[s] = true
+	defaultHub.mu.Unlock()
+
+	return s
+}
+
+func Unsubscribe(s *Subscription) {
+	defaultHub.mu.Lock()
+	defer defaultHub.mu.Unlock()
+
+	if defaultHub.subscriptions[s] {
+		delete(defaultHub.subscriptions, s)
+		close(s.Messages)
+	}
+}
+
+func (h *hub) broadcast(n *notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for s := range h.subscriptions {
+		if !s.filter.matches(n) {
+			continue
+		}
+
+		select {
+		case s.Messages <- n.Message:
+		default:
+			log.Default().Println("Dropping stream that fell behind")
+			delete(h.subscriptions, s)
+			close(s.Messages)
+		}
+	}
+}
+
+// Listen forwards notifications published by any process to the streams of this one, reconnecting
+// until ctx is cancelled. Messages published while reconnecting are lost.
+func Listen(ctx context.Context, connString string) {
+	delay := time.Second
+
+	for {
+		started := time.Now()
+		err := listen(ctx, connString)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// A connection that held up for a while starts over with a short delay
+		if time.Since(started) > maxReconnectDelay {
+			delay = time.Second
+		}
+
+		log.Default().Println("Stream listener disconnected, reconnecting in", delay, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+func listen(ctx context.Context, connString string) error {
+	conn, err := pgx.Connect(ctx, connString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return err
+	}
+
+	log.Default().Println("Listening for stream messages")
+
+	for {
+		pgNotification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var n notification
+		if err := json.Unmarshal([]byte(pgNotification.Payload), &n); err != nil {
+			log.Default().Println("Ignoring malformed stream message", err)
+			continue
+		}
+
+		defaultHub.broadcast(&n)
+	}
+}
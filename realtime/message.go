@@ -0,0 +1,123 @@
+package realtime
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Every process publishes to this channel, the API processes listen to it and fan out to their streams
+const channel = "geo_tracker_stream"
+
+// Postgres rejects notification payloads of 8000 bytes and more
+const maxPayloadSize = 7900
+
+type MessageType string
+
+const (
+	MessageLocation     MessageType = "location"
+	MessageDeviceStatus MessageType = "device_status"
+	MessageEvent        MessageType = "event"
+	MessageComment      MessageType = "comment"
+)
+
+// Message is what stream clients receive. Data holds the created or changed object, it is omitted
+// when the object is too large for a notification and clients fetch it by its ID instead.
+type Message struct {
+	Type      MessageType     `json:"type"`
+	DeviceID  *uuid.UUID      `json:"device_id,omitempty"`
+	EventID   *uuid.UUID      `json:"event_id,omitempty"`
+	Latitude  *float64        `json:"latitude,omitempty"`
+	Longitude *float64        `json:"longitude,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// notification is the message with what decides who may receive it, never sent to clients
+type notification struct {
+	Message
+	Public       bool        `json:"public"`
+	CreatedByID  *uuid.UUID  `json:"created_by_id,omitempty"`
+	CommunityIDs []uuid.UUID `json:"community_ids,omitempty"`
+}
+
+func publish(db *gorm.DB, n notification, data interface{}) error {
+	var err error
+	if n.Data, err = json.Marshal(data); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	if len(payload) > maxPayloadSize {
+		n.Data = nil
+		if payload, err = json.Marshal(n); err != nil {
+			return err
+		}
+	}
+
+	return db.Exec("SELECT pg_notify(?, ?)", channel, string(payload)).Error
+}
+
+// PublishLocation streams a new position of the device
+func PublishLocation(db *gorm.DB, location *models.GPSLocation) {
+	n := notification{Message: Message{
+		Type:      MessageLocation,
+		DeviceID:  &location.DeviceID,
+		Latitude:  &location.Latitude,
+		Longitude: &location.Longitude,
+	}}
+
+	if err := publish(db, n, location); err != nil {
+		log.Default().Println("Failed to publish location of device", location.DeviceID, err)
+	}
+}
+
+// PublishDeviceStatus streams an online or offline transition of the device
+func PublishDeviceStatus(db *gorm.DB, device *models.GPSDevice) {
+	n := notification{Message: Message{Type: MessageDeviceStatus, DeviceID: &device.ID}}
+
+	status := map[string]interface{}{"status": device.Status, "last_seen_at": device.LastSeenAt}
+	if err := publish(db, n, status); err != nil {
+		log.Default().Println("Failed to publish status of device", device.ID, err)
+	}
+}
+
+// PublishEvent streams a new event to everyone allowed to see it
+func PublishEvent(db *gorm.DB, event *models.Event) {
+	if err := publish(db, eventNotification(MessageEvent, event), event); err != nil {
+		log.Default().Println("Failed to publish event", event.ID, err)
+	}
+}
+
+// PublishComment streams a new comment to everyone allowed to see its event
+func PublishComment(db *gorm.DB, event *models.Event, comment *models.Comment) {
+	if err := publish(db, eventNotification(MessageComment, event), comment); err != nil {
+		log.Default().Println("Failed to publish comment", comment.ID, err)
+	}
+}
+
+func eventNotification(messageType MessageType, event *models.Event) notification {
+	communityIDs := make([]uuid.UUID, len(event.Communities))
+	for i, community := range event.Communities {
+		communityIDs[i] = community.ID
+	}
+
+	return notification{
+		Message: Message{
+			Type:      messageType,
+			DeviceID:  event.DeviceID,
+			EventID:   &event.ID,
+			Latitude:  &event.Latitude,
+			Longitude: &event.Longitude,
+		},
+		Public:       event.IsPublic != nil && *event.IsPublic,
+		CreatedByID:  &event.CreatedByID,
+		CommunityIDs: communityIDs,
+	}
+}
@@ -0,0 +1,193 @@
+package realtime
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stream clients only send control frames, anything larger is a misbehaving client
+const maxClientFrameSize = 4096
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	opText  byte = 0x1
+	opClose byte = 0x8
+	opPing  byte = 0x9
+	opPong  byte = 0xA
+)
+
+var ErrNotWebSocket = errors.New("not a websocket handshake")
+
+// WebSocket is the server side of a RFC 6455 connection, limited to what streaming needs:
+// sending text frames and answering pings and close frames of the client.
+type WebSocket struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	mu     sync.Mutex
+}
+
+func headerContains(header http.Header, name string, value string) bool {
+	for _, v := range header.Values(name) {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// UpgradeWebSocket completes the handshake and takes over the connection from the HTTP server
+func UpgradeWebSocket(w http.ResponseWriter, r *http.Request) (*WebSocket, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet || key == "" ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, ErrNotWebSocket
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("connection can't be taken over")
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha1.Sum([]byte(key + websocketGUID))
+	accept := base64.StdEncoding.EncodeToString(hash[:])
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + accept + "\r\n\r\n"
+
+	if _, err := conn.Write([]byte(response)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &WebSocket{conn: conn, reader: rw.Reader}, nil
+}
+
+func (ws *WebSocket) WriteText(payload []byte) error {
+	return ws.writeFrame(opText, payload)
+}
+
+// Ping keeps proxies from closing an idle stream
+func (ws *WebSocket) Ping() error {
+	return ws.writeFrame(opPing, nil)
+}
+
+func (ws *WebSocket) Close() error {
+	ws.writeFrame(opClose, nil)
+	return ws.conn.Close()
+}
+
+func (ws *WebSocket) writeFrame(opcode byte, payload []byte) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	// Server frames are never masked
+	header := []byte{0x80 | opcode}
+	switch {
+	case len(payload) < 126:
+		header = append(header, byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(len(payload)))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(len(payload)))
+	}
+
+	ws.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := ws.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReadLoop answers control frames until the client closes the connection or the connection fails
+func (ws *WebSocket) ReadLoop() error {
+	for {
+		opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return err
+		}
+
+		switch opcode {
+		case opPing:
+			if err := ws.writeFrame(opPong, payload); err != nil {
+				return err
+			}
+		case opClose:
+			ws.writeFrame(opClose, nil)
+			return io.EOF
+		}
+	}
+}
+
+func (ws *WebSocket) readFrame() (byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(ws.reader, header); err != nil {
+		return 0, nil, err
+	}
+
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	if !masked {
+		return 0, nil, errors.New("client frames must be masked")
+	}
+
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		if _, err := io.ReadFull(ws.reader, extended); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		if _, err := io.ReadFull(ws.reader, extended); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+
+	if length > maxClientFrameSize {
+		return 0, nil, errors.New("client frame too large")
+	}
+
+	mask := make([]byte, 4)
+	if _, err := io.ReadFull(ws.reader, mask); err != nil {
+		return 0, nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(ws.reader, payload); err != nil {
+		return 0, nil, err
+	}
+
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return opcode, payload, nil
+}
@@ -1,107 +1,182 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"log"
-	"time"
 
 	"github.com/Hodik/geo-tracker-be/config"
+	"github.com/Hodik/geo-tracker-be/coordination"
+	"github.com/Hodik/geo-tracker-be/health"
+	"github.com/Hodik/geo-tracker-be/ingestion"
 	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/poller"
+	"github.com/Hodik/geo-tracker-be/providers"
+	"github.com/Hodik/geo-tracker-be/secrets"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-func SetAPICookie(db *gorm.DB, device *models.GPSDevice) {
-	cookie := createSessionCookie()
-	login(cookie, *device.Imei, *device.Password)
-	device.APICookie = &cookie
-	db.Save(&device)
-}
-
-func ReceiveDeviceLocation(db *gorm.DB, device *models.GPSDevice) (*models.GPSLocation, error) {
-
-	if device.Imei == nil || device.Password == nil {
-		return nil, errors.New("Non GPS device, cannot get location")
+func targetProvider(target *poller.Target) models.DeviceProviderType {
+	if target.Account != nil {
+		return target.Account.Provider
 	}
 
-	log.Default().Println("Polling device", device.ID)
+	return target.Devices[0].Provider
+}
 
-	if device.APICookie == nil {
-		SetAPICookie(db, device)
+func targetSession(target *poller.Target) *secrets.Secret {
+	if target.Account != nil {
+		return target.Account.APICookie
 	}
 
-	lat, lon, err := getLocation(*device.APICookie)
-
-	if errors.Is(err, InvalidCookieError) {
-		SetAPICookie(db, device)
-
-		lat, lon, err = getLocation(*device.APICookie)
+	return target.Devices[0].APICookie
+}
 
+// SetAPICookie logs in with the account of the target, or the IMEI and password of a standalone device
+func SetAPICookie(ctx context.Context, db *gorm.DB, provider providers.DeviceProvider, target *poller.Target) error {
+	if target.Account != nil {
+		cookie, err := provider.Login(ctx, providers.Credentials{Username: target.Account.Username, Password: string(target.Account.Password)})
 		if err != nil {
-			return nil, err
+			return err
 		}
-	}
 
-	location := models.GPSLocation{
-		Latitude:  lat,
-		Longitude: lon,
-		DeviceID:  device.ID,
+		session := secrets.Secret(cookie)
+		target.Account.APICookie = &session
+		return db.Model(target.Account).Update("api_cookie", session).Error
 	}
 
-	result := db.Save(&location)
-	if result.Error != nil {
-		return nil, result.Error
+	device := target.Devices[0]
+	cookie, err := provider.Login(ctx, providers.Credentials{Username: *device.Imei, Password: string(*device.Password)})
+	if err != nil {
+		return err
 	}
 
-	return &location, nil
+	session := secrets.Secret(cookie)
+	device.APICookie = &session
+	return db.Model(device).Update("api_cookie", session).Error
 }
 
-func CleanUpLocations(db *gorm.DB, device *models.GPSDevice) error {
+// ReceiveTargetLocations fetches the positions of every device of the target with a single session
+func ReceiveTargetLocations(ctx context.Context, db *gorm.DB, target *poller.Target) error {
+	provider, err := providers.Get(targetProvider(target))
+	if err != nil {
+		return err
+	}
 
-	log.Default().Println("Cleaning up locations for device", device.ID)
+	log.Default().Println("Polling", len(target.Devices), "devices of", target.ID, "via", targetProvider(target))
 
-	var recentLocations []models.GPSLocation
-	if err := db.Where("device_id = ?", device.ID).Order("created_at desc").Limit(5).Find(&recentLocations).Error; err != nil {
-		return err
+	if targetSession(target) == nil {
+		if err := SetAPICookie(ctx, db, provider, target); err != nil {
+			return err
+		}
 	}
 
-	var recentIDs []uuid.UUID
-	for _, location := range recentLocations {
-		recentIDs = append(recentIDs, location.ID)
+	positions, err := provider.FetchPositions(ctx, string(*targetSession(target)), target.Devices)
+
+	if errors.Is(err, providers.ErrSessionExpired) {
+		if err := SetAPICookie(ctx, db, provider, target); err != nil {
+			return err
+		}
+
+		positions, err = provider.FetchPositions(ctx, string(*targetSession(target)), target.Devices)
 	}
 
-	if err := db.Where("device_id = ? AND id NOT IN ?", device.ID, recentIDs).Delete(&models.GPSLocation{}).Error; err != nil {
+	if err != nil {
 		return err
 	}
 
+	for _, device := range target.Devices {
+		devicePositions := positions[device.ID]
+
+		if len(devicePositions) == 0 {
+			log.Default().Println("Device", device.ID, "has no position in its provider account")
+			if err := health.RecordFailure(db, device, providers.ErrNoPositions); err != nil {
+				log.Default().Println("Failed to record health of device", device.ID, err)
+			}
+			continue
+		}
+
+		location := devicePositions[len(devicePositions)-1].ToGPSLocation()
+
+		// The other devices of the account are stored anyway, only this one is reported
+		if err := ingestion.StoreLocations(db, device, []*models.GPSLocation{location}); err != nil {
+			log.Default().Println("Failed to store location of device", device.ID, err)
+			if err := health.RecordFailure(db, device, err); err != nil {
+				log.Default().Println("Failed to record health of device", device.ID, err)
+			}
+		}
+	}
+
+	if len(positions) == 0 {
+		return providers.ErrNoPositions
+	}
+
 	return nil
 }
 
-func PollDevices(db *gorm.DB) {
-	log.Default().Println("Location server started")
-	var devices []models.GPSDevice
+// LoadPollTargets groups tracked devices by provider account, devices with their own credentials are polled alone
+func LoadPollTargets(db *gorm.DB) ([]poller.Target, error) {
+	var devices []*models.GPSDevice
+	if err := db.Preload("Account").
+		Where("tracking = ? AND provider IN ? AND imei IS NOT NULL AND (account_id IS NOT NULL OR password IS NOT NULL)", true, providers.Types()).
+		Find(&devices).Error; err != nil {
+		return nil, err
+	}
 
-	for {
-		db.Where("tracking = ? AND imei IS NOT NULL AND password IS NOT NULL", true).Find(&devices)
+	if len(devices) == 0 {
+		return nil, nil
+	}
 
-		log.Default().Println("Pulling locations for ", len(devices), " devices")
-		for _, device := range devices {
-			go func() {
-				_, err := ReceiveDeviceLocation(db, &device)
-				if err != nil {
-					log.Default().Println("Failed to receive device location", err)
-				}
-				err = CleanUpLocations(db, &device)
+	intervals, err := PollIntervals(db, config.GetConfig(db), devices)
+	if err != nil {
+		return nil, err
+	}
 
-				if err != nil {
-					log.Default().Println("Failed to clean up locations", err)
-				}
+	var targets []poller.Target
+	accounts := map[uuid.UUID]int{}
 
-			}()
+	for _, device := range devices {
+		// Devices detached from a deleted account may have no credentials of their own
+		if device.Account == nil && device.Password == nil {
+			continue
 		}
 
-		conf := config.GetConfig(db)
-		log.Default().Println("Sleeping for ", conf.PollInterval, " seconds")
-		time.Sleep(time.Duration(conf.PollInterval) * time.Second)
+		if device.Account == nil {
+			targets = append(targets, poller.Target{ID: device.ID, Devices: []*models.GPSDevice{device}, Interval: intervals[device.ID]})
+			continue
+		}
+
+		// One session fetches every device of the account, the most frequently polled device sets the pace
+		if i, ok := accounts[device.Account.ID]; ok {
+			targets[i].Devices = append(targets[i].Devices, device)
+			targets[i].Interval = min(targets[i].Interval, intervals[device.ID])
+			continue
+		}
+
+		accounts[device.Account.ID] = len(targets)
+		targets = append(targets, poller.Target{ID: device.Account.ID, Account: device.Account, Devices: []*models.GPSDevice{device}, Interval: intervals[device.ID]})
 	}
+
+	return targets, nil
+}
+
+// pollTarget records a failed poll against every device of the target so failing trackers surface as needing attention
+func pollTarget(ctx context.Context, db *gorm.DB, target *poller.Target) error {
+	err := ReceiveTargetLocations(ctx, db, target)
+	if err == nil || errors.Is(err, providers.ErrNoPositions) {
+		return err
+	}
+
+	for _, device := range target.Devices {
+		if err := health.RecordFailure(db, device, err); err != nil {
+			log.Default().Println("Failed to record health of device", device.ID, err)
+		}
+	}
+
+	return err
+}
+
+func PollDevices(ctx context.Context, db *gorm.DB, replica *coordination.Replica) {
+	poller.New(db, replica, LoadPollTargets, pollTarget).Run(ctx)
 }
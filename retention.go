@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/config"
+	"github.com/Hodik/geo-tracker-be/coordination"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type deviceRetention struct {
+	ID            uuid.UUID
+	RetentionDays int
+}
+
+// ScheduleRetention periodically enforces location retention, independently from polling.
+func ScheduleRetention(ctx context.Context, db *gorm.DB, replica *coordination.Replica) {
+	for {
+		conf := config.GetConfig(db)
+
+		// Only the leader replica deletes, concurrent batches would just fight over the same rows
+		if replica.IsLeader() {
+			if err := ApplyRetention(db); err != nil {
+				log.Default().Println("Failed to apply location retention", err)
+			}
+		}
+
+		log.Default().Println("Next retention run in", conf.RetentionJobMinutes, "minutes")
+		if !sleepContext(ctx, time.Duration(conf.RetentionJobMinutes)*time.Minute) {
+			return
+		}
+	}
+}
+
+func ApplyRetention(db *gorm.DB) error {
+	conf := config.GetConfig(db)
+
+	// Device setting wins, otherwise the community requiring the longest history decides
+	var devices []deviceRetention
+	if err := db.Raw(`
+		SELECT gps_devices.id, COALESCE(gps_devices.retention_days, MAX(communities.location_retention_days), ?) AS retention_days
+		FROM gps_devices
+		LEFT JOIN community_tracking ON community_tracking.gps_device_id = gps_devices.id
+		LEFT JOIN communities ON communities.id = community_tracking.community_id AND communities.deleted_at IS NULL
+		GROUP BY gps_devices.id
+	`, conf.LocationRetentionDays).Scan(&devices).Error; err != nil {
+		return err
+	}
+
+	log.Default().Println("Applying location retention for", len(devices), "devices")
+
+	for _, device := range devices {
+		cutoff := time.Now().AddDate(0, 0, -device.RetentionDays)
+
+		deleted, err := deleteInBatches(db, `
+			DELETE FROM gps_locations WHERE id IN (
+				SELECT id FROM gps_locations WHERE device_id = ? AND (fix_time < ? OR deleted_at IS NOT NULL) LIMIT ?
+			)`, int(conf.RetentionBatchSize), device.ID, cutoff)
+		if err != nil {
+			return err
+		}
+
+		if conf.LocationDownsampleMinutes > 0 {
+			downsampled, err := downsampleLocations(db, device.ID, conf.LocationDownsampleAfterDays, conf.LocationDownsampleMinutes, int(conf.RetentionBatchSize))
+			if err != nil {
+				return err
+			}
+			deleted += downsampled
+		}
+
+		if deleted > 0 {
+			log.Default().Println("Removed", deleted, "locations of device", device.ID)
+		}
+	}
+
+	return nil
+}
+
+// downsampleLocations keeps only the first point of every interval for locations older than afterDays,
+// preferring a point that passed the ingestion filters.
+func downsampleLocations(db *gorm.DB, deviceID uuid.UUID, afterDays uint, intervalMinutes uint, batchSize int) (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -int(afterDays))
+
+	return deleteInBatches(db, `
+		DELETE FROM gps_locations WHERE id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (
+					PARTITION BY FLOOR(EXTRACT(EPOCH FROM fix_time) / ?)
+					ORDER BY filter_reason IS NOT NULL, fix_time
+				) AS bucket_row
+				FROM gps_locations
+				WHERE device_id = ? AND fix_time < ?
+			) ranked
+			WHERE bucket_row > 1
+			LIMIT ?
+		)`, batchSize, intervalMinutes*60, deviceID, cutoff)
+}
+
+// deleteInBatches runs query until it deletes less than batchSize rows, the batch size must be the last argument.
+func deleteInBatches(db *gorm.DB, query string, batchSize int, args ...interface{}) (int64, error) {
+	var total int64
+	args = append(args, batchSize)
+
+	for {
+		result := db.Exec(query, args...)
+		if result.Error != nil {
+			return total, result.Error
+		}
+
+		total += result.RowsAffected
+
+		if result.RowsAffected < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
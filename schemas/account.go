@@ -0,0 +1,49 @@
+package schemas
+
+import (
+	"errors"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/providers"
+	"github.com/Hodik/geo-tracker-be/secrets"
+)
+
+type CreateProviderAccount struct {
+	Provider *models.DeviceProviderType `json:"provider"`
+	Username string                     `json:"username" binding:"required"`
+	Password string                     `json:"password" binding:"required"`
+}
+
+type UpdateProviderAccount struct {
+	Password *string `json:"password"`
+}
+
+func (c *CreateProviderAccount) ToProviderAccount(creator *models.User) (*models.ProviderAccount, error) {
+	provider := models.Provider365GPS
+	if c.Provider != nil {
+		if err := models.ValidateDeviceProvider(string(*c.Provider)); err != nil {
+			return nil, err
+		}
+		provider = *c.Provider
+	}
+
+	// Push providers authenticate each device with its own token, only polled providers have accounts
+	if _, err := providers.Get(provider); err != nil {
+		return nil, errors.New("provider doesn't support accounts")
+	}
+
+	return &models.ProviderAccount{
+		Provider:    provider,
+		Username:    c.Username,
+		Password:    secrets.Secret(c.Password),
+		CreatedByID: creator.ID,
+	}, nil
+}
+
+func (u *UpdateProviderAccount) ToProviderAccount(existing *models.ProviderAccount) {
+	if u.Password != nil {
+		existing.Password = secrets.Secret(*u.Password)
+		// The old session was opened with the old password
+		existing.APICookie = nil
+	}
+}
@@ -0,0 +1,5 @@
+package schemas
+
+type CreateDeviceCommand struct {
+	Name string `json:"name" binding:"required"`
+}
@@ -16,6 +16,7 @@ type CreateCommunity struct {
 	IncludeExternalEvents         *bool                 `json:"include_external_events"`
 	AllowReadOnlyMembersAddEvents *bool                 `json:"allow_read_only_members_add_events"`
 	TrackingDevices               *[]uuid.UUID          `json:"tracking_devices"`
+	LocationRetentionDays         *int                  `json:"location_retention_days"`
 }
 
 type UpdateCommunity struct {
@@ -26,6 +27,7 @@ type UpdateCommunity struct {
 	IncludeExternalEvents         *bool                 `json:"include_external_events"`
 	AllowReadOnlyMembersAddEvents *bool                 `json:"allow_read_only_members_add_events"`
 	PolygonArea                   *string               `json:"polygon_area"`
+	LocationRetentionDays         *int                  `json:"location_retention_days"`
 }
 
 type CreateCommunityInvite struct {
@@ -96,7 +98,7 @@ func (u *UpdateCommunityInvite) ToCommunityInvite(existing *models.CommunityInvi
 	return nil
 }
 
-func (c *CreateCommunity) ToCommunity(db *gorm.DB) (*models.Community, error) {
+func (c *CreateCommunity) ToCommunity(db *gorm.DB, creator *models.User) (*models.Community, error) {
 
 	if c.Type != nil {
 		if err := models.ValidateCommunityType(string(*c.Type)); err != nil {
@@ -114,9 +116,15 @@ func (c *CreateCommunity) ToCommunity(db *gorm.DB) (*models.Community, error) {
 	AllowReadOnlyMembersAddEvents := c.AllowReadOnlyMembersAddEvents != nil && *c.AllowReadOnlyMembersAddEvents || communityType == models.PUBLIC
 	AppearsInSearch := c.AppearsInSearch != nil && *c.AppearsInSearch || communityType == models.PUBLIC
 
+	if c.LocationRetentionDays != nil {
+		if err := ValidateRetentionDays(*c.LocationRetentionDays); err != nil {
+			return nil, err
+		}
+	}
+
 	var devices []*models.GPSDevice
 	if c.TrackingDevices != nil {
-		if result := db.Where("id IN ?", *c.TrackingDevices).Find(&devices); result.Error != nil {
+		if result := db.Where("id IN ? AND id IN (?)", *c.TrackingDevices, models.DevicesWithRole(db, creator, models.DeviceRoleViewer)).Find(&devices); result.Error != nil {
 			return nil, result.Error
 		}
 	}
@@ -130,6 +138,7 @@ func (c *CreateCommunity) ToCommunity(db *gorm.DB) (*models.Community, error) {
 			AllowReadOnlyMembersAddEvents: &AllowReadOnlyMembersAddEvents,
 			IncludeExternalEvents:         &IncludeExternalEvents,
 			TrackingDevices:               devices,
+			LocationRetentionDays:         c.LocationRetentionDays,
 		},
 		nil
 }
@@ -167,5 +176,13 @@ func (u *UpdateCommunity) ToCommunity(existing *models.Community, user *models.U
 		existing.Type = *u.Type
 	}
 
+	if u.LocationRetentionDays != nil {
+		if err := ValidateRetentionDays(*u.LocationRetentionDays); err != nil {
+			return err
+		}
+
+		existing.LocationRetentionDays = u.LocationRetentionDays
+	}
+
 	return nil
 }
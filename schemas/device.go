@@ -1,45 +1,115 @@
 package schemas
 
-import "github.com/Hodik/geo-tracker-be/models"
+import (
+	"errors"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/secrets"
+	"github.com/google/uuid"
+)
 
 type CreateGPSDevice struct {
-	Imei     *string `json:"imei"`
-	Password *string `json:"password"`
+	Imei     *string                    `json:"imei"`
+	Password *string                    `json:"password"`
+	Provider *models.DeviceProviderType `json:"provider"`
+	// AccountID polls the device through a shared provider account instead of its own IMEI and password
+	AccountID *uuid.UUID `json:"account_id"`
 
 	Number      *string `json:"number"`
 	Tracking    *bool   `json:"tracking"`
 	Name        *string `json:"name"`
 	Description *string `json:"description"`
+	Timezone    *string `json:"timezone"`
+
+	RetentionDays       *int  `json:"retention_days"`
+	PollIntervalSeconds *uint `json:"poll_interval_seconds"`
+	AdaptivePolling     *bool `json:"adaptive_polling"`
 }
 
 type UpdateGPSDevice struct {
-	Number   *string `json:"number"`
-	Imei     *string `json:"imei"`
-	Tracking *bool   `json:"tracking"`
+	Number    *string    `json:"number"`
+	Imei      *string    `json:"imei"`
+	Password  *string    `json:"password"`
+	Tracking  *bool      `json:"tracking"`
+	AccountID *uuid.UUID `json:"account_id"`
+	// ClearAccount detaches the device from its provider account, it is polled with its own credentials again
+	ClearAccount bool `json:"clear_account"`
 
 	Name        *string `json:"name"`
 	Description *string `json:"description"`
+	Timezone    *string `json:"timezone"`
+
+	RetentionDays       *int  `json:"retention_days"`
+	PollIntervalSeconds *uint `json:"poll_interval_seconds"`
+	AdaptivePolling     *bool `json:"adaptive_polling"`
 }
 
-func (c *CreateGPSDevice) ToGPSDevice(creator *models.User) *models.GPSDevice {
+func (c *CreateGPSDevice) ToGPSDevice(creator *models.User) (*models.GPSDevice, error) {
 	if c.Imei == nil {
 		defaultTracking := false
 		c.Tracking = &defaultTracking
 	}
 
+	provider := models.Provider365GPS
+	if c.Provider != nil {
+		if err := models.ValidateDeviceProvider(string(*c.Provider)); err != nil {
+			return nil, err
+		}
+		provider = *c.Provider
+	}
+
+	if c.Timezone != nil {
+		if err := ValidateTimezone(*c.Timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.RetentionDays != nil {
+		if err := ValidateRetentionDays(*c.RetentionDays); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.PollIntervalSeconds != nil {
+		if err := ValidatePollInterval(*c.PollIntervalSeconds); err != nil {
+			return nil, err
+		}
+	}
+
+	var password *secrets.Secret
+	if c.Password != nil {
+		secret := secrets.Secret(*c.Password)
+		password = &secret
+	}
+
 	d := &models.GPSDevice{
 		Number:      c.Number,
 		Imei:        c.Imei,
-		Password:    c.Password,
+		Password:    password,
+		AccountID:   c.AccountID,
 		Tracking:    c.Tracking,
+		Provider:    provider,
 		CreatedByID: &creator.ID,
 		Name:        c.Name,
 		Description: c.Description,
+		Timezone:    c.Timezone,
+
+		RetentionDays:       c.RetentionDays,
+		PollIntervalSeconds: c.PollIntervalSeconds,
+		AdaptivePolling:     c.AdaptivePolling,
+	}
+
+	if provider == models.ProviderOsmAnd {
+		if err := d.GenerateIngestToken(); err != nil {
+			return nil, err
+		}
 	}
-	return d
+
+	return d, nil
 }
 
-func (u *UpdateGPSDevice) ToGPSDevice(existing *models.GPSDevice) {
+func (u *UpdateGPSDevice) ToGPSDevice(existing *models.GPSDevice) error {
 	if u.Number != nil {
 		existing.Number = u.Number
 	}
@@ -48,10 +118,29 @@ func (u *UpdateGPSDevice) ToGPSDevice(existing *models.GPSDevice) {
 		existing.Imei = u.Imei
 	}
 
+	if u.Password != nil {
+		password := secrets.Secret(*u.Password)
+		existing.Password = &password
+		// The old session was opened with the old password
+		existing.APICookie = nil
+	}
+
 	if u.Tracking != nil {
 		existing.Tracking = u.Tracking
 	}
 
+	if u.AccountID != nil && u.ClearAccount {
+		return errors.New("account_id and clear_account are mutually exclusive")
+	}
+
+	if u.AccountID != nil {
+		existing.AccountID = u.AccountID
+	}
+
+	if u.ClearAccount {
+		existing.AccountID = nil
+	}
+
 	if u.Name != nil {
 		existing.Name = u.Name
 	}
@@ -59,4 +148,110 @@ func (u *UpdateGPSDevice) ToGPSDevice(existing *models.GPSDevice) {
 	if u.Description != nil {
 		existing.Description = u.Description
 	}
+
+	if u.Timezone != nil {
+		if err := ValidateTimezone(*u.Timezone); err != nil {
+			return err
+		}
+		existing.Timezone = u.Timezone
+	}
+
+	if u.RetentionDays != nil {
+		if err := ValidateRetentionDays(*u.RetentionDays); err != nil {
+			return err
+		}
+		existing.RetentionDays = u.RetentionDays
+	}
+
+	if u.PollIntervalSeconds != nil {
+		if err := ValidatePollInterval(*u.PollIntervalSeconds); err != nil {
+			return err
+		}
+		existing.PollIntervalSeconds = u.PollIntervalSeconds
+	}
+
+	if u.AdaptivePolling != nil {
+		existing.AdaptivePolling = u.AdaptivePolling
+	}
+
+	return nil
+}
+
+// GPSDeviceResponse is a device as returned to clients. Credentials never leave the server, the ingest
+// token is only included for users allowed to configure the device.
+type GPSDeviceResponse struct {
+	ID            uuid.UUID                 `json:"id"`
+	CreatedAt     time.Time                 `json:"created_at"`
+	UpdatedAt     time.Time                 `json:"updated_at"`
+	Imei          *string                   `json:"imei"`
+	Provider      models.DeviceProviderType `json:"provider"`
+	AccountID     *uuid.UUID                `json:"account_id"`
+	HasPassword   bool                      `json:"has_password"`
+	IngestToken   *string                   `json:"ingest_token,omitempty"`
+	Number        *string                   `json:"number"`
+	Tracking      *bool                     `json:"tracking"`
+	Name          *string                   `json:"name"`
+	Description   *string                   `json:"description"`
+	Timezone      *string                   `json:"timezone"`
+	RetentionDays *int                      `json:"retention_days"`
+	CreatedByID   *uuid.UUID                `json:"created_by"`
+
+	PollIntervalSeconds *uint `json:"poll_interval_seconds"`
+	AdaptivePolling     *bool `json:"adaptive_polling"`
+
+	Status       models.DeviceStatus `json:"status"`
+	LastSeenAt   *time.Time          `json:"last_seen_at"`
+	LastFixAt    *time.Time          `json:"last_fix_at"`
+	Battery      *float64            `json:"battery"`
+	Signal       *float64            `json:"signal"`
+	PollFailures int                 `json:"poll_failures"`
+	LastError    *string             `json:"last_error"`
+	LastErrorAt  *time.Time          `json:"last_error_at"`
+
+	Locations []models.GPSLocation `json:"locations"`
+}
+
+func ToGPSDeviceResponse(d *models.GPSDevice, withIngestToken bool) GPSDeviceResponse {
+	response := GPSDeviceResponse{
+		ID:            d.ID,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+		Imei:          d.Imei,
+		Provider:      d.Provider,
+		AccountID:     d.AccountID,
+		HasPassword:   d.Password != nil,
+		Number:        d.Number,
+		Tracking:      d.Tracking,
+		Name:          d.Name,
+		Description:   d.Description,
+		Timezone:      d.Timezone,
+		RetentionDays: d.RetentionDays,
+		CreatedByID:   d.CreatedByID,
+
+		PollIntervalSeconds: d.PollIntervalSeconds,
+		AdaptivePolling:     d.AdaptivePolling,
+		Status:              d.Status,
+		LastSeenAt:          d.LastSeenAt,
+		LastFixAt:           d.LastFixAt,
+		Battery:             d.Battery,
+		Signal:              d.Signal,
+		PollFailures:        d.PollFailures,
+		LastError:           d.LastError,
+		LastErrorAt:         d.LastErrorAt,
+		Locations:           d.Locations,
+	}
+
+	if withIngestToken {
+		response.IngestToken = d.IngestToken
+	}
+
+	return response
+}
+
+func ToGPSDeviceResponses(devices []models.GPSDevice) []GPSDeviceResponse {
+	responses := make([]GPSDeviceResponse, len(devices))
+	for i := range devices {
+		responses[i] = ToGPSDeviceResponse(&devices[i], false)
+	}
+	return responses
 }
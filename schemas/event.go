@@ -8,6 +8,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// ErrEventDeviceNotFound is returned when the device to place an event at doesn't exist or the user can't see it
+var ErrEventDeviceNotFound = errors.New("device not found")
+
 type CreateEvent struct {
 	Title       string              `json:"title" binding:"required"`
 	Description string              `json:"description" binding:"required"`
@@ -76,9 +79,30 @@ func (c *CreateEvent) ToEvent(db *gorm.DB, user *models.User) (*models.Event, er
 		Latitude = *c.Latitude
 		Longitude = *c.Longitude
 	} else if c.DeviceID != nil {
+		var device models.GPSDevice
+		err := db.Where("id = ?", c.DeviceID).First(&device).Error
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEventDeviceNotFound
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		canView, err := device.HasRole(db, user, models.DeviceRoleViewer)
+		if err != nil {
+			return nil, err
+		}
+
+		if !canView {
+			return nil, ErrEventDeviceNotFound
+		}
+
 		var latestLocation models.GPSLocation
 
-		if err := db.Where("device_id = ?", c.DeviceID).Order("created_at DESC").First(&latestLocation).Error; err != nil {
+		if err := db.Where("device_id = ? AND filter_reason IS NULL AND deleted_at IS NULL", device.ID).
+			Order("fix_time DESC").First(&latestLocation).Error; err != nil {
 			return nil, err
 		}
 
@@ -0,0 +1,57 @@
+package schemas
+
+import (
+	"errors"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/google/uuid"
+)
+
+type CreateGeofenceRule struct {
+	Name             *string               `json:"name"`
+	AreaOfInterestID *uuid.UUID            `json:"area_of_interest_id"`
+	AreaOfInterest   *CreateAreaOfInterest `json:"area_of_interest"`
+	Trigger          string                `json:"trigger" binding:"required"`
+	DwellMinutes     *uint                 `json:"dwell_minutes"`
+}
+
+func (c *CreateGeofenceRule) ToGeofenceRule(device *models.GPSDevice, creator *models.User) (*models.GeofenceRule, error) {
+	if (c.AreaOfInterestID == nil) == (c.AreaOfInterest == nil) {
+		return nil, errors.New("exactly one of area_of_interest_id or area_of_interest must be provided")
+	}
+
+	if err := models.ValidateGeofenceTrigger(c.Trigger); err != nil {
+		return nil, err
+	}
+
+	trigger := models.GeofenceTrigger(c.Trigger)
+
+	if trigger == models.GeofenceDwell && (c.DwellMinutes == nil || *c.DwellMinutes == 0) {
+		return nil, errors.New("dwell_minutes is required for dwell trigger")
+	}
+
+	if trigger != models.GeofenceDwell && c.DwellMinutes != nil {
+		return nil, errors.New("dwell_minutes is only allowed for dwell trigger")
+	}
+
+	rule := &models.GeofenceRule{
+		Name:         c.Name,
+		DeviceID:     device.ID,
+		Trigger:      trigger,
+		DwellMinutes: c.DwellMinutes,
+		CreatedByID:  creator.ID,
+	}
+
+	if c.AreaOfInterestID != nil {
+		rule.AreaOfInterestID = *c.AreaOfInterestID
+		return rule, nil
+	}
+
+	aoi, err := c.AreaOfInterest.ToAreaOfInterest()
+	if err != nil {
+		return nil, err
+	}
+
+	rule.AreaOfInterest = aoi
+	return rule, nil
+}
@@ -0,0 +1,217 @@
+package schemas
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/google/uuid"
+)
+
+// Bulk requests are applied device by device, keep them to the size of a real fleet
+const MaxGroupDevices = 1000
+
+type CreateDeviceGroup struct {
+	Name        string      `json:"name" binding:"required"`
+	Description *string     `json:"description"`
+	DeviceIDs   []uuid.UUID `json:"device_ids"`
+	// PollIntervalSeconds and AdaptivePolling apply to the members without their own setting
+	PollIntervalSeconds *uint `json:"poll_interval_seconds"`
+	AdaptivePolling     *bool `json:"adaptive_polling"`
+}
+
+type UpdateDeviceGroup struct {
+	Name                *string `json:"name"`
+	Description         *string `json:"description"`
+	PollIntervalSeconds *uint   `json:"poll_interval_seconds"`
+	AdaptivePolling     *bool   `json:"adaptive_polling"`
+}
+
+type GroupDevices struct {
+	DeviceIDs []uuid.UUID `json:"device_ids" binding:"required"`
+}
+
+// BulkDeviceAction is applied to every member of a group, exactly one action must be set
+type BulkDeviceAction struct {
+	Tracking *bool `json:"tracking"`
+	// Command is the name of a command from the catalog of each device
+	Command *string `json:"command"`
+	// CommunityID makes the community track the devices, the user must be one of its admins
+	CommunityID         *uuid.UUID `json:"community_id"`
+	PollIntervalSeconds *uint      `json:"poll_interval_seconds"`
+	AdaptivePolling     *bool      `json:"adaptive_polling"`
+}
+
+type BulkActionFailure struct {
+	DeviceID uuid.UUID `json:"device_id"`
+	Error    string    `json:"error"`
+}
+
+type BulkActionResult struct {
+	Succeeded []uuid.UUID         `json:"succeeded"`
+	Failed    []BulkActionFailure `json:"failed"`
+}
+
+type DeviceGroupResponse struct {
+	ID                  uuid.UUID   `json:"id"`
+	CreatedAt           time.Time   `json:"created_at"`
+	UpdatedAt           time.Time   `json:"updated_at"`
+	Name                string      `json:"name"`
+	Description         *string     `json:"description"`
+	OwnerID             uuid.UUID   `json:"owner_id"`
+	DeviceIDs           []uuid.UUID `json:"device_ids"`
+	PollIntervalSeconds *uint       `json:"poll_interval_seconds"`
+	AdaptivePolling     *bool       `json:"adaptive_polling"`
+}
+
+type DevicePosition struct {
+	DeviceID   uuid.UUID           `json:"device_id"`
+	Name       *string             `json:"name"`
+	Status     models.DeviceStatus `json:"status"`
+	LastSeenAt *time.Time          `json:"last_seen_at"`
+	// Location is the latest accepted fix, null for devices that never reported one
+	Location *models.GPSLocation `json:"location"`
+}
+
+type DeviceGroupStatus struct {
+	Total    int `json:"total"`
+	Tracking int `json:"tracking"`
+	Online   int `json:"online"`
+	Offline  int `json:"offline"`
+	Unknown  int `json:"unknown"`
+	// Attention lists the tracked members needing attention, with the same reasons as the attention endpoint
+	Attention []DeviceAttention `json:"attention"`
+}
+
+func (c *CreateDeviceGroup) ToDeviceGroup(owner *models.User) (*models.DeviceGroup, error) {
+	if err := validateGroupName(c.Name); err != nil {
+		return nil, err
+	}
+
+	if len(c.DeviceIDs) > MaxGroupDevices {
+		return nil, errors.New("too many devices in a group")
+	}
+
+	if c.PollIntervalSeconds != nil {
+		if err := ValidatePollInterval(*c.PollIntervalSeconds); err != nil {
+			return nil, err
+		}
+	}
+
+	return &models.DeviceGroup{
+		Name:                c.Name,
+		Description:         c.Description,
+		OwnerID:             owner.ID,
+		PollIntervalSeconds: c.PollIntervalSeconds,
+		AdaptivePolling:     c.AdaptivePolling,
+	}, nil
+}
+
+func (u *UpdateDeviceGroup) ToDeviceGroup(existing *models.DeviceGroup) error {
+	if u.Name != nil {
+		if err := validateGroupName(*u.Name); err != nil {
+			return err
+		}
+		existing.Name = *u.Name
+	}
+
+	if u.Description != nil {
+		existing.Description = u.Description
+	}
+
+	if u.PollIntervalSeconds != nil {
+		if err := ValidatePollInterval(*u.PollIntervalSeconds); err != nil {
+			return err
+		}
+		existing.PollIntervalSeconds = u.PollIntervalSeconds
+	}
+
+	if u.AdaptivePolling != nil {
+		existing.AdaptivePolling = u.AdaptivePolling
+	}
+
+	return nil
+}
+
+func (g *GroupDevices) Validate() error {
+	if len(g.DeviceIDs) == 0 {
+		return errors.New("device_ids must not be empty")
+	}
+
+	if len(g.DeviceIDs) > MaxGroupDevices {
+		return errors.New("too many devices in a group")
+	}
+
+	return nil
+}
+
+func (b *BulkDeviceAction) Validate() error {
+	actions := 0
+	for _, set := range []bool{b.Tracking != nil, b.Command != nil, b.CommunityID != nil, b.PollIntervalSeconds != nil, b.AdaptivePolling != nil} {
+		if set {
+			actions++
+		}
+	}
+
+	if actions != 1 {
+		return errors.New("exactly one of tracking, command, community_id, poll_interval_seconds or adaptive_polling must be provided")
+	}
+
+	if b.PollIntervalSeconds != nil {
+		return ValidatePollInterval(*b.PollIntervalSeconds)
+	}
+
+	return nil
+}
+
+func validateGroupName(name string) error {
+	if name == "" {
+		return errors.New("name must not be empty")
+	}
+
+	return nil
+}
+
+func ToDeviceGroupResponse(g *models.DeviceGroup, deviceIDs []uuid.UUID) DeviceGroupResponse {
+	if deviceIDs == nil {
+		deviceIDs = []uuid.UUID{}
+	}
+
+	return DeviceGroupResponse{
+		ID:                  g.ID,
+		CreatedAt:           g.CreatedAt,
+		UpdatedAt:           g.UpdatedAt,
+		Name:                g.Name,
+		Description:         g.Description,
+		OwnerID:             g.OwnerID,
+		DeviceIDs:           deviceIDs,
+		PollIntervalSeconds: g.PollIntervalSeconds,
+		AdaptivePolling:     g.AdaptivePolling,
+	}
+}
+
+func ToDeviceGroupStatus(devices []models.GPSDevice, conf *models.Config, now time.Time) DeviceGroupStatus {
+	status := DeviceGroupStatus{Total: len(devices), Attention: []DeviceAttention{}}
+
+	for _, device := range devices {
+		switch device.Status {
+		case models.DeviceStatusOnline:
+			status.Online++
+		case models.DeviceStatusOffline:
+			status.Offline++
+		default:
+			status.Unknown++
+		}
+
+		if device.Tracking == nil || !*device.Tracking {
+			continue
+		}
+		status.Tracking++
+
+		if attention := ToDeviceAttention(device, conf, now); len(attention.Reasons) > 0 {
+			status.Attention = append(status.Attention, attention)
+		}
+	}
+
+	return status
+}
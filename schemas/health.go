@@ -0,0 +1,46 @@
+package schemas
+
+import (
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+)
+
+const (
+	AttentionOffline     = "offline"
+	AttentionNeverSeen   = "never_seen"
+	AttentionStaleFix    = "stale_fix"
+	AttentionLowBattery  = "low_battery"
+	AttentionPollFailing = "poll_failing"
+)
+
+type DeviceAttention struct {
+	Device  GPSDeviceResponse `json:"device"`
+	Reasons []string          `json:"reasons"`
+}
+
+// ToDeviceAttention explains why a device needs attention, the checks mirror the attention query
+func ToDeviceAttention(device models.GPSDevice, conf *models.Config, now time.Time) DeviceAttention {
+	cutoff := now.Add(-time.Duration(conf.DeviceOfflineMinutes) * time.Minute)
+	reasons := []string{}
+
+	switch {
+	case device.LastSeenAt == nil:
+		reasons = append(reasons, AttentionNeverSeen)
+	case device.Status == models.DeviceStatusOffline:
+		reasons = append(reasons, AttentionOffline)
+	case device.LastFixAt == nil || device.LastFixAt.Before(cutoff):
+		// Heard from, but without a position lately, e.g. a tracker indoors sending heartbeats only
+		reasons = append(reasons, AttentionStaleFix)
+	}
+
+	if device.Battery != nil && *device.Battery < conf.LowBatteryPercent {
+		reasons = append(reasons, AttentionLowBattery)
+	}
+
+	if device.PollFailures >= conf.AttentionPollFailures {
+		reasons = append(reasons, AttentionPollFailing)
+	}
+
+	return DeviceAttention{Device: ToGPSDeviceResponse(&device, false), Reasons: reasons}
+}
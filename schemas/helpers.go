@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
 )
 
 func ValidatePolygonWKT(polygon string) error {
@@ -35,3 +38,67 @@ func ValidatePolygonWKT(polygon string) error {
 
 	return nil
 }
+
+func ValidateTimezone(timezone string) error {
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return fmt.Errorf("invalid timezone: '%s'", timezone)
+	}
+
+	return nil
+}
+
+func ValidateRetentionDays(days int) error {
+	if days < 1 {
+		return errors.New("retention days must be at least 1")
+	}
+
+	return nil
+}
+
+func ValidatePollInterval(seconds uint) error {
+	if seconds < models.MinPollIntervalSeconds || seconds > models.MaxPollIntervalSeconds {
+		return errors.New("poll interval must be between 10 seconds and one day")
+	}
+
+	return nil
+}
+
+// ValidateIMEI checks the 15 digits of an IMEI against its Luhn check digit, typos in bulk imports are common
+func ValidateIMEI(imei string) error {
+	if len(imei) != 15 {
+		return errors.New("imei must have 15 digits")
+	}
+
+	sum := 0
+	for i, r := range imei {
+		if r < '0' || r > '9' {
+			return errors.New("imei must have 15 digits")
+		}
+
+		digit := int(r - '0')
+		if i%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+
+	if sum%10 != 0 {
+		return errors.New("imei check digit doesn't match")
+	}
+
+	return nil
+}
+
+var e164Regex = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// ValidatePhoneNumber requires numbers in E.164, the format Twilio sends commands to and reports replies from
+func ValidatePhoneNumber(number string) error {
+	if !e164Regex.MatchString(number) {
+		return fmt.Errorf("invalid phone number: '%s', expected E.164 like +380501234567", number)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package schemas
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/tracks"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultLocationPageSize = 500
+	MaxLocationPageSize     = 5000
+)
+
+type LocationHistoryQuery struct {
+	From   *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Cursor string     `form:"cursor"`
+	Limit  int        `form:"limit"`
+	Format string     `form:"format"`
+	// IncludeFiltered also returns points rejected on ingestion, marked with their filter reason
+	IncludeFiltered bool `form:"include_filtered"`
+}
+
+type LocationPage struct {
+	Items      []models.GPSLocation `json:"items"`
+	NextCursor *string              `json:"next_cursor"`
+}
+
+type LocationCursor struct {
+	FixTime time.Time
+	ID      uuid.UUID
+}
+
+func (q *LocationHistoryQuery) Validate() error {
+	if q.Format == "" {
+		q.Format = string(tracks.FormatJSON)
+	}
+
+	if err := tracks.ValidateExportFormat(q.Format); err != nil {
+		return err
+	}
+
+	if q.Limit == 0 {
+		q.Limit = DefaultLocationPageSize
+	}
+
+	if q.Limit < 0 || q.Limit > MaxLocationPageSize {
+		return errors.New("limit must be between 1 and 5000")
+	}
+
+	if q.From != nil && q.To != nil && q.From.After(*q.To) {
+		return errors.New("from must be before to")
+	}
+
+	return nil
+}
+
+// Apply restricts tx to the requested time range and, when set, to locations after the cursor.
+func (q *LocationHistoryQuery) Apply(tx *gorm.DB) (*gorm.DB, error) {
+	if q.From != nil {
+		tx = tx.Where("fix_time >= ?", *q.From)
+	}
+
+	if q.To != nil {
+		tx = tx.Where("fix_time <= ?", *q.To)
+	}
+
+	if !q.IncludeFiltered {
+		tx = tx.Where("filter_reason IS NULL")
+	}
+
+	if q.Cursor != "" {
+		cursor, err := DecodeLocationCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where("(fix_time, id) > (?, ?)", cursor.FixTime, cursor.ID)
+	}
+
+	return tx.Order("fix_time, id"), nil
+}
+
+func ToLocationPage(locations []models.GPSLocation, limit int) *LocationPage {
+	page := &LocationPage{Items: locations}
+
+	if len(locations) > limit {
+		page.Items = locations[:limit]
+		last := page.Items[limit-1]
+		cursor := LocationCursor{FixTime: last.FixTime, ID: last.ID}.Encode()
+		page.NextCursor = &cursor
+	}
+
+	return page
+}
+
+func (c LocationCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.FixTime.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()))
+}
+
+func DecodeLocationCursor(cursor string) (*LocationCursor, error) {
+	invalid := errors.New("invalid cursor")
+
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+
+	parts := strings.SplitN(string(b), "|", 2)
+	if len(parts) != 2 {
+		return nil, invalid
+	}
+
+	fixTime, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, invalid
+	}
+
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return nil, invalid
+	}
+
+	return &LocationCursor{FixTime: fixTime, ID: id}, nil
+}
+
+type TrackImportResult struct {
+	Imported   int `json:"imported"`
+	Duplicates int `json:"duplicates"`
+	Invalid    int `json:"invalid"`
+}
@@ -0,0 +1,106 @@
+package schemas
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+)
+
+// OsmAndTimestamp accepts unix seconds, unix milliseconds or an ISO 8601 date,
+// the formats sent by the OsmAnd and Traccar clients.
+type OsmAndTimestamp struct {
+	time.Time
+}
+
+type OsmAndLocation struct {
+	ID        string          `form:"id" json:"id"`
+	Lat       *float64        `form:"lat" json:"lat"`
+	Lon       *float64        `form:"lon" json:"lon"`
+	Timestamp OsmAndTimestamp `form:"timestamp" json:"timestamp" swaggertype:"string"`
+	Speed     *float64        `form:"speed" json:"speed"`
+	Bearing   *float64        `form:"bearing" json:"bearing"`
+	Altitude  *float64        `form:"altitude" json:"altitude"`
+	Accuracy  *float64        `form:"accuracy" json:"accuracy"`
+	Batt      *float64        `form:"batt" json:"batt"`
+}
+
+type OsmAndBatch struct {
+	ID        string           `json:"id" binding:"required"`
+	Locations []OsmAndLocation `json:"locations" binding:"required"`
+}
+
+func (t *OsmAndTimestamp) UnmarshalParam(param string) error {
+	param = strings.TrimSpace(param)
+	if param == "" {
+		return nil
+	}
+
+	if value, err := strconv.ParseFloat(param, 64); err == nil {
+		// anything past year 2286 in seconds is a millisecond timestamp
+		if value > 1e10 {
+			value = value / 1000
+		}
+		seconds := int64(value)
+		t.Time = time.Unix(seconds, int64((value-float64(seconds))*1e9)).UTC()
+		return nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05"} {
+		if parsed, err := time.Parse(layout, param); err == nil {
+			t.Time = parsed.UTC()
+			return nil
+		}
+	}
+
+	return errors.New("invalid timestamp: " + param)
+}
+
+func (t *OsmAndTimestamp) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case float64:
+		return t.UnmarshalParam(strconv.FormatFloat(v, 'f', -1, 64))
+	case string:
+		return t.UnmarshalParam(v)
+	default:
+		return errors.New("invalid timestamp")
+	}
+}
+
+func (o *OsmAndLocation) ToGPSLocation() (*models.GPSLocation, error) {
+	if o.Lat == nil || o.Lon == nil {
+		return nil, errors.New("lat and lon are required")
+	}
+
+	if *o.Lat < -90 || *o.Lat > 90 || *o.Lon < -180 || *o.Lon > 180 {
+		return nil, errors.New("lat or lon out of range")
+	}
+
+	location := &models.GPSLocation{
+		Latitude:  *o.Lat,
+		Longitude: *o.Lon,
+		FixTime:   o.Timestamp.Time,
+		Course:    o.Bearing,
+		Altitude:  o.Altitude,
+		Accuracy:  o.Accuracy,
+		Battery:   o.Batt,
+	}
+
+	// OsmAnd reports speed in knots
+	if o.Speed != nil {
+		speed := *o.Speed * 1.852
+		location.Speed = &speed
+	}
+
+	return location, nil
+}
@@ -1,8 +1,60 @@
 package schemas
 
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
 type Paginated struct {
 	Page     int         `json:"page"`
 	PageSize int         `json:"page_size"`
 	Total    int         `json:"total"`
 	Items    interface{} `json:"items"`
 }
+
+type TimeRangeQuery struct {
+	From     *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page     int        `form:"page"`
+	PageSize int        `form:"page_size"`
+}
+
+func (q *TimeRangeQuery) Validate() error {
+	if q.Page == 0 {
+		q.Page = 1
+	}
+
+	if q.PageSize == 0 {
+		q.PageSize = 10
+	}
+
+	if q.Page < 0 {
+		return errors.New("page must be positive")
+	}
+
+	if q.PageSize < 0 || q.PageSize > 500 {
+		return errors.New("page_size must be between 1 and 500")
+	}
+
+	if q.From != nil && q.To != nil && q.From.After(*q.To) {
+		return errors.New("from must be before to")
+	}
+
+	return nil
+}
+
+// Apply keeps rows overlapping the requested range, latest first. Columns are trusted, never pass user input.
+func (q *TimeRangeQuery) Apply(tx *gorm.DB, startColumn string, endColumn string) *gorm.DB {
+	if q.From != nil {
+		tx = tx.Where(fmt.Sprintf("%s >= ?", endColumn), *q.From)
+	}
+
+	if q.To != nil {
+		tx = tx.Where(fmt.Sprintf("%s <= ?", startColumn), *q.To)
+	}
+
+	return tx.Order(startColumn + " DESC")
+}
@@ -0,0 +1,181 @@
+package schemas
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/google/uuid"
+)
+
+// Rows are validated against the database one by one, keep imports to the size of a real fleet
+const MaxDeviceImportRows = 1000
+
+type DeviceImport struct {
+	Devices []CreateGPSDevice `json:"devices" binding:"required"`
+}
+
+type DeviceImportQuery struct {
+	// DryRun validates every row without creating anything
+	DryRun bool `form:"dry_run"`
+	// CommunityID makes the community track the created devices, the user must be one of its admins
+	CommunityID *string `form:"community_id"`
+}
+
+type DeviceImportRow struct {
+	// Row is the 1-based position of the device in the request, the header of a CSV file is not counted
+	Row      int        `json:"row"`
+	Imei     *string    `json:"imei"`
+	Number   *string    `json:"number"`
+	DeviceID *uuid.UUID `json:"device_id"`
+	Errors   []string   `json:"errors"`
+}
+
+type DeviceImportResult struct {
+	DryRun  bool              `json:"dry_run"`
+	Valid   int               `json:"valid"`
+	Invalid int               `json:"invalid"`
+	Created int               `json:"created"`
+	Rows    []DeviceImportRow `json:"rows"`
+}
+
+// deviceCSVColumns maps the CSV header to the fields of CreateGPSDevice, named like the JSON fields
+var deviceCSVColumns = map[string]func(d *CreateGPSDevice, value string) error{
+	"imei":        func(d *CreateGPSDevice, value string) error { d.Imei = &value; return nil },
+	"password":    func(d *CreateGPSDevice, value string) error { d.Password = &value; return nil },
+	"number":      func(d *CreateGPSDevice, value string) error { d.Number = &value; return nil },
+	"name":        func(d *CreateGPSDevice, value string) error { d.Name = &value; return nil },
+	"description": func(d *CreateGPSDevice, value string) error { d.Description = &value; return nil },
+	"timezone":    func(d *CreateGPSDevice, value string) error { d.Timezone = &value; return nil },
+	"provider": func(d *CreateGPSDevice, value string) error {
+		provider := models.DeviceProviderType(value)
+		d.Provider = &provider
+		return nil
+	},
+	"account_id": func(d *CreateGPSDevice, value string) error {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return errors.New("invalid account_id")
+		}
+		d.AccountID = &id
+		return nil
+	},
+	"tracking": func(d *CreateGPSDevice, value string) error {
+		tracking, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("invalid tracking, expected true or false")
+		}
+		d.Tracking = &tracking
+		return nil
+	},
+	"retention_days": func(d *CreateGPSDevice, value string) error {
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.New("invalid retention_days")
+		}
+		d.RetentionDays = &days
+		return nil
+	},
+	"poll_interval_seconds": func(d *CreateGPSDevice, value string) error {
+		seconds, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return errors.New("invalid poll_interval_seconds")
+		}
+		interval := uint(seconds)
+		d.PollIntervalSeconds = &interval
+		return nil
+	},
+	"adaptive_polling": func(d *CreateGPSDevice, value string) error {
+		adaptive, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("invalid adaptive_polling, expected true or false")
+		}
+		d.AdaptivePolling = &adaptive
+		return nil
+	},
+}
+
+// ParseDeviceCSV reads devices from a CSV file with a header row. Empty cells are left unset, cells that
+// can't be parsed are returned as errors of their row so the whole file can still be reported on.
+func ParseDeviceCSV(r io.Reader) ([]CreateGPSDevice, map[int][]string, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("csv file is empty")
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if _, ok := deviceCSVColumns[column]; !ok {
+			return nil, nil, fmt.Errorf("unknown csv column: '%s'", column)
+		}
+		header[i] = column
+	}
+
+	devices := []CreateGPSDevice{}
+	rowErrors := map[int][]string{}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(devices) == MaxDeviceImportRows {
+			return nil, nil, fmt.Errorf("at most %d devices can be imported at once", MaxDeviceImportRows)
+		}
+
+		var device CreateGPSDevice
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+
+			if err := deviceCSVColumns[header[i]](&device, value); err != nil {
+				rowErrors[len(devices)+1] = append(rowErrors[len(devices)+1], err.Error())
+			}
+		}
+
+		devices = append(devices, device)
+	}
+
+	return devices, rowErrors, nil
+}
+
+// ValidateImport checks the identifiers of an imported device, stricter than a single create as
+// the rows were typed in a spreadsheet rather than picked in the app
+func (c *CreateGPSDevice) ValidateImport() []string {
+	errs := []string{}
+
+	// OsmAnd clients are identified by their ingest token
+	if c.Imei == nil {
+		if c.Provider == nil || *c.Provider != models.ProviderOsmAnd {
+			errs = append(errs, "imei is required")
+		}
+	} else if err := ValidateIMEI(*c.Imei); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if c.Number != nil {
+		if err := ValidatePhoneNumber(*c.Number); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	return errs
+}
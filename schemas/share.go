@@ -0,0 +1,79 @@
+package schemas
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/google/uuid"
+)
+
+type CreateDeviceShare struct {
+	UserID      *uuid.UUID `json:"user_id"`
+	CommunityID *uuid.UUID `json:"community_id"`
+	Role        *string    `json:"role"`
+	// ExpiresAt ends the share, it never expires when omitted
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type UpdateDeviceShare struct {
+	Role      *string    `json:"role"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (c *CreateDeviceShare) ToDeviceShare(device *models.GPSDevice, creator *models.User) (*models.DeviceShare, error) {
+	if (c.UserID == nil) == (c.CommunityID == nil) {
+		return nil, errors.New("exactly one of user_id or community_id must be provided")
+	}
+
+	if c.UserID != nil && device.CreatedByID != nil && *c.UserID == *device.CreatedByID {
+		return nil, errors.New("the owner can't share the device with themselves")
+	}
+
+	role := models.DeviceRoleViewer
+	if c.Role != nil {
+		if err := models.ValidateDeviceShareRole(*c.Role); err != nil {
+			return nil, err
+		}
+		role = models.DeviceRole(*c.Role)
+	}
+
+	if err := validateShareExpiry(c.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	return &models.DeviceShare{
+		DeviceID:    device.ID,
+		UserID:      c.UserID,
+		CommunityID: c.CommunityID,
+		Role:        role,
+		ExpiresAt:   c.ExpiresAt,
+		CreatedByID: creator.ID,
+	}, nil
+}
+
+func (u *UpdateDeviceShare) ToDeviceShare(existing *models.DeviceShare) error {
+	if u.Role != nil {
+		if err := models.ValidateDeviceShareRole(*u.Role); err != nil {
+			return err
+		}
+		existing.Role = models.DeviceRole(*u.Role)
+	}
+
+	if u.ExpiresAt != nil {
+		if err := validateShareExpiry(u.ExpiresAt); err != nil {
+			return err
+		}
+		existing.ExpiresAt = u.ExpiresAt
+	}
+
+	return nil
+}
+
+func validateShareExpiry(expiresAt *time.Time) error {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return errors.New("expires_at must be in the future")
+	}
+
+	return nil
+}
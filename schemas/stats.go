@@ -0,0 +1,188 @@
+package schemas
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/google/uuid"
+)
+
+const (
+	StatsGranularityDay   = "day"
+	StatsGranularityWeek  = "week"
+	StatsGranularityMonth = "month"
+
+	StatsFormatJSON = "json"
+	StatsFormatCSV  = "csv"
+
+	// A report covers at most a few years of daily rollups
+	MaxStatsDays = 3 * 366
+	// Reports cover the last 30 days by default
+	defaultStatsDays = 30
+)
+
+// DeviceStatsQuery is a range of days in the timezone of the device, both ends included
+type DeviceStatsQuery struct {
+	From        *time.Time `form:"from" time_format:"2006-01-02"`
+	To          *time.Time `form:"to" time_format:"2006-01-02"`
+	Granularity string     `form:"granularity"`
+	Format      string     `form:"format"`
+}
+
+type DeviceStatsPeriod struct {
+	// Start and End are the first and last day of the period within the requested range
+	Start          string  `json:"start"`
+	End            string  `json:"end"`
+	DistanceMeters float64 `json:"distance_meters"`
+	MovingSeconds  int64   `json:"moving_seconds"`
+	IdleSeconds    int64   `json:"idle_seconds"`
+	MaxSpeed       float64 `json:"max_speed"`
+}
+
+type DeviceStats struct {
+	DeviceID    uuid.UUID `json:"device_id"`
+	Timezone    string    `json:"timezone"`
+	Granularity string    `json:"granularity"`
+	// OdometerMeters is the distance travelled by the device up to the end of the range
+	OdometerMeters float64             `json:"odometer_meters"`
+	Total          DeviceStatsPeriod   `json:"total"`
+	Periods        []DeviceStatsPeriod `json:"periods"`
+}
+
+// Validate fills the defaults, today is the current day of the device
+func (q *DeviceStatsQuery) Validate(today time.Time) error {
+	if q.Granularity == "" {
+		q.Granularity = StatsGranularityDay
+	}
+
+	switch q.Granularity {
+	case StatsGranularityDay, StatsGranularityWeek, StatsGranularityMonth:
+	default:
+		return errors.New("granularity must be day, week or month")
+	}
+
+	if q.Format == "" {
+		q.Format = StatsFormatJSON
+	}
+
+	if q.Format != StatsFormatJSON && q.Format != StatsFormatCSV {
+		return errors.New("format must be json or csv")
+	}
+
+	if q.To == nil {
+		to := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+		q.To = &to
+	}
+
+	if q.From == nil {
+		from := q.To.AddDate(0, 0, -(defaultStatsDays - 1))
+		q.From = &from
+	}
+
+	if q.From.After(*q.To) {
+		return errors.New("from must be before to")
+	}
+
+	if q.To.Sub(*q.From) >= MaxStatsDays*24*time.Hour {
+		return errors.New("range must not exceed 1098 days")
+	}
+
+	return nil
+}
+
+// periodStart is the first day of the period containing day, weeks start on Monday
+func (q *DeviceStatsQuery) periodStart(day time.Time) time.Time {
+	switch q.Granularity {
+	case StatsGranularityWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case StatsGranularityMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+func (q *DeviceStatsQuery) nextPeriod(start time.Time) time.Time {
+	switch q.Granularity {
+	case StatsGranularityWeek:
+		return start.AddDate(0, 0, 7)
+	case StatsGranularityMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// ToDeviceStats sums the daily rollups into periods. Every period of the range is listed, days without
+// rollups count as days without movement.
+func ToDeviceStats(device *models.GPSDevice, query *DeviceStatsQuery, days []models.DeviceDailyStats, odometer float64) DeviceStats {
+	stats := DeviceStats{
+		DeviceID:       device.ID,
+		Timezone:       device.Location().String(),
+		Granularity:    query.Granularity,
+		OdometerMeters: odometer,
+		Total:          DeviceStatsPeriod{Start: query.From.Format(time.DateOnly), End: query.To.Format(time.DateOnly)},
+		Periods:        []DeviceStatsPeriod{},
+	}
+
+	index := map[string]int{}
+	for start := query.periodStart(*query.From); !start.After(*query.To); start = query.nextPeriod(start) {
+		end := query.nextPeriod(start).AddDate(0, 0, -1)
+
+		period := DeviceStatsPeriod{Start: start.Format(time.DateOnly), End: end.Format(time.DateOnly)}
+		if start.Before(*query.From) {
+			period.Start = query.From.Format(time.DateOnly)
+		}
+		if end.After(*query.To) {
+			period.End = query.To.Format(time.DateOnly)
+		}
+
+		index[start.Format(time.DateOnly)] = len(stats.Periods)
+		stats.Periods = append(stats.Periods, period)
+	}
+
+	for _, day := range days {
+		i, ok := index[query.periodStart(day.Day).Format(time.DateOnly)]
+		if !ok {
+			continue
+		}
+
+		for _, period := range []*DeviceStatsPeriod{&stats.Periods[i], &stats.Total} {
+			period.DistanceMeters += day.DistanceMeters
+			period.MovingSeconds += day.MovingSeconds
+			period.IdleSeconds += day.IdleSeconds
+			period.MaxSpeed = max(period.MaxSpeed, day.MaxSpeed)
+		}
+	}
+
+	return stats
+}
+
+// WriteCSV writes a row per period, the total is left to spreadsheets
+func (s *DeviceStats) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"start", "end", "distance_meters", "moving_seconds", "idle_seconds", "max_speed"}); err != nil {
+		return err
+	}
+
+	for _, period := range s.Periods {
+		if err := writer.Write([]string{
+			period.Start,
+			period.End,
+			strconv.FormatFloat(period.DistanceMeters, 'f', 1, 64),
+			strconv.FormatInt(period.MovingSeconds, 10),
+			strconv.FormatInt(period.IdleSeconds, 10),
+			strconv.FormatFloat(period.MaxSpeed, 'f', 1, 64),
+		}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
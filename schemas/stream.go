@@ -0,0 +1,83 @@
+package schemas
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/realtime"
+	"github.com/google/uuid"
+)
+
+type StreamQuery struct {
+	Devices     string `form:"devices"`
+	Communities string `form:"communities"`
+	BBox        string `form:"bbox"`
+}
+
+type StreamTicket struct {
+	// Ticket is passed as the ticket query parameter of the stream request
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DeviceIDs parses the comma separated device IDs
+func (q *StreamQuery) DeviceIDs() ([]uuid.UUID, error) {
+	return parseUUIDList(q.Devices)
+}
+
+// CommunityIDs parses the comma separated community IDs
+func (q *StreamQuery) CommunityIDs() ([]uuid.UUID, error) {
+	return parseUUIDList(q.Communities)
+}
+
+// BoundingBox parses bbox given as min_lon,min_lat,max_lon,max_lat, nil when not set
+func (q *StreamQuery) BoundingBox() (*realtime.BoundingBox, error) {
+	if q.BBox == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(q.BBox, ",")
+	if len(parts) != 4 {
+		return nil, errors.New("bbox must be min_lon,min_lat,max_lon,max_lat")
+	}
+
+	values := make([]float64, 4)
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, errors.New("bbox must be min_lon,min_lat,max_lon,max_lat")
+		}
+		values[i] = value
+	}
+
+	bbox := &realtime.BoundingBox{MinLongitude: values[0], MinLatitude: values[1], MaxLongitude: values[2], MaxLatitude: values[3]}
+
+	if bbox.MinLatitude < -90 || bbox.MaxLatitude > 90 || bbox.MinLongitude < -180 || bbox.MaxLongitude > 180 {
+		return nil, errors.New("bbox is out of range")
+	}
+
+	if bbox.MinLatitude > bbox.MaxLatitude || bbox.MinLongitude > bbox.MaxLongitude {
+		return nil, errors.New("bbox minimum must be below its maximum")
+	}
+
+	return bbox, nil
+}
+
+func parseUUIDList(list string) ([]uuid.UUID, error) {
+	if list == "" {
+		return nil, nil
+	}
+
+	var ids []uuid.UUID
+	for _, part := range strings.Split(list, ",") {
+		id, err := uuid.Parse(strings.TrimSpace(part))
+		if err != nil {
+			return nil, errors.New("invalid id " + part)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,192 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Encrypted values are stored as prefix:key id:wrapped data key:ciphertext, both parts in base64.
+// Every value has its own data key, so rotating the key encryption key only rewraps data keys.
+const prefix = "enc:v1"
+
+var ErrUnknownKey = errors.New("value is encrypted with an unknown key")
+var ErrMalformed = errors.New("malformed encrypted value")
+
+// IsEncrypted tells encrypted values from plaintext stored before encryption was introduced
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix+":")
+}
+
+// Encrypt encrypts plaintext with a new data key wrapped by the primary key
+func Encrypt(plaintext string) (string, error) {
+	if ring == nil {
+		return "", ErrNotConfigured
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	wrapped, err := seal(ring.keys[ring.primary], dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	return format(ring.primary, wrapped, ciphertext), nil
+}
+
+// Decrypt returns the plaintext of an encrypted value, plaintext values are returned as they are
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	if ring == nil {
+		return "", ErrNotConfigured
+	}
+
+	keyID, wrapped, ciphertext, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	key, ok := ring.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w %s", ErrUnknownKey, keyID)
+	}
+
+	dataKey, err := open(key, wrapped)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(dataKey, ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// NeedsRewrap reports whether the value is plaintext or encrypted with a key other than the primary one
+func NeedsRewrap(value string) bool {
+	if !IsEncrypted(value) {
+		return true
+	}
+
+	keyID, _, _, err := parse(value)
+	return err == nil && ring != nil && keyID != ring.primary
+}
+
+// Rewrap encrypts plaintext values and moves values encrypted with an older key to the primary key
+func Rewrap(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return Encrypt(value)
+	}
+
+	if ring == nil {
+		return "", ErrNotConfigured
+	}
+
+	keyID, wrapped, ciphertext, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	if keyID == ring.primary {
+		return value, nil
+	}
+
+	key, ok := ring.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w %s", ErrUnknownKey, keyID)
+	}
+
+	dataKey, err := open(key, wrapped)
+	if err != nil {
+		return "", err
+	}
+
+	rewrapped, err := seal(ring.keys[ring.primary], dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return format(ring.primary, rewrapped, ciphertext), nil
+}
+
+func format(keyID string, wrapped []byte, ciphertext []byte) string {
+	return strings.Join([]string{prefix, keyID, base64.RawStdEncoding.EncodeToString(wrapped), base64.RawStdEncoding.EncodeToString(ciphertext)}, ":")
+}
+
+func parse(value string) (string, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(value, prefix+":"), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, ErrMalformed
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+
+	return parts[0], wrapped, ciphertext, nil
+}
+
+// seal encrypts with AES-256-GCM, the nonce is prepended to the ciphertext
+func seal(key []byte, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key []byte, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrMalformed
+	}
+
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,112 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// keyfile is the layout of CREDENTIALS_KEYFILE, the same as exported by most KMS tools for local keys:
+// every key ever used to encrypt credentials, and the primary one encrypting new values.
+type keyfile struct {
+	Primary string `json:"primary"`
+	Keys    []struct {
+		ID  string `json:"id"`
+		Key string `json:"key"`
+	} `json:"keys"`
+}
+
+type keyring struct {
+	primary string
+	keys    map[string][]byte
+}
+
+var ring *keyring
+
+var ErrNotConfigured = errors.New("credential encryption keys are not configured")
+
+// Setup loads the key encryption keys from CREDENTIALS_KEYFILE, or from CREDENTIALS_KEYS given as
+// comma separated id:base64key pairs with CREDENTIALS_PRIMARY_KEY naming the one used for new values.
+// Keys are 32 bytes. To rotate, add a new key, make it primary and run the migrator to rewrap stored values.
+// Without keys the process still starts, plaintext credentials stay readable and storing a credential fails,
+// unless CREDENTIALS_REQUIRED is true as in deployments, where missing keys are a misconfiguration.
+func Setup() {
+	r, err := loadKeyring()
+	if errors.Is(err, ErrNotConfigured) && os.Getenv("CREDENTIALS_REQUIRED") != "true" {
+		log.Println("CREDENTIALS_KEYFILE and CREDENTIALS_KEYS are not set, provider credentials can't be stored")
+		return
+	}
+
+	if err != nil {
+		log.Fatalln("Failed to load credential encryption keys:", err)
+	}
+
+	ring = r
+	log.Println("Credential encryption keys loaded, primary key", r.primary)
+}
+
+// Configured reports whether credentials can be encrypted
+func Configured() bool {
+	return ring != nil
+}
+
+func loadKeyring() (*keyring, error) {
+	var file keyfile
+
+	if path := os.Getenv("CREDENTIALS_KEYFILE"); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(b, &file); err != nil {
+			return nil, fmt.Errorf("invalid keyfile: %w", err)
+		}
+	} else if keys := os.Getenv("CREDENTIALS_KEYS"); keys != "" {
+		file.Primary = os.Getenv("CREDENTIALS_PRIMARY_KEY")
+
+		for _, pair := range strings.Split(keys, ",") {
+			id, key, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			if !ok {
+				return nil, errors.New("CREDENTIALS_KEYS must be comma separated id:key pairs")
+			}
+
+			file.Keys = append(file.Keys, struct {
+				ID  string `json:"id"`
+				Key string `json:"key"`
+			}{ID: id, Key: key})
+		}
+
+		// A single key needs no primary
+		if file.Primary == "" && len(file.Keys) == 1 {
+			file.Primary = file.Keys[0].ID
+		}
+	} else {
+		return nil, ErrNotConfigured
+	}
+
+	r := &keyring{primary: file.Primary, keys: map[string][]byte{}}
+
+	for _, k := range file.Keys {
+		if k.ID == "" || strings.Contains(k.ID, ":") {
+			return nil, fmt.Errorf("invalid key id %q", k.ID)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(k.Key)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("key %s must be 32 bytes encoded in base64", k.ID)
+		}
+
+		r.keys[k.ID] = key
+	}
+
+	if _, ok := r.keys[r.primary]; !ok {
+		return nil, fmt.Errorf("primary key %q is not among the keys", r.primary)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,42 @@
+package secrets
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// Secret is a credential encrypted transparently when written to the database and decrypted when read.
+// It never leaves the process in JSON.
+type Secret string
+
+func (s Secret) Value() (driver.Value, error) {
+	return Encrypt(string(s))
+}
+
+func (s *Secret) Scan(value interface{}) error {
+	var stored string
+	switch v := value.(type) {
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("unsupported scan type for Secret: %T", value)
+	}
+
+	plaintext, err := Decrypt(stored)
+	if err != nil {
+		return err
+	}
+
+	*s = Secret(plaintext)
+	return nil
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`null`), nil
+}
+
+func (s Secret) String() string {
+	return "[redacted]"
+}
@@ -2,10 +2,14 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/Hodik/geo-tracker-be/config"
 	"github.com/Hodik/geo-tracker-be/database"
 	"github.com/Hodik/geo-tracker-be/dbconn"
+	"github.com/Hodik/geo-tracker-be/messaging"
+	"github.com/Hodik/geo-tracker-be/providers"
+	"github.com/Hodik/geo-tracker-be/secrets"
 	"github.com/joho/godotenv"
 )
 
@@ -14,12 +18,21 @@ func setupApp() {
 	database.SetupDBConnection()
 	database.WaitForMigratedDB()
 	config.GetConfig(dbconn.GetDB())
+	secrets.Setup()
+	providers.Setup()
+
+	// SMS is optional, deployments without Twilio just don't get SMS trackers
+	if os.Getenv("TWILIO_AUTH_TOKEN") != "" {
+		messaging.Setup()
+	}
+
 	log.Println("Setup complete")
 }
 
 func setupMigrator() {
 	setupEnv()
 	database.SetupDBConnection()
+	secrets.Setup()
 	log.Println("Setup complete")
 }
 
@@ -0,0 +1,149 @@
+package simulator
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/tracks"
+)
+
+// A route moves a virtual device along, elapsed is simulated time since the previous call
+type route interface {
+	Advance(elapsed time.Duration) (latitude float64, longitude float64)
+}
+
+// trackRoute replays a recorded track with its original timing, then plays it backwards so the device
+// never jumps from the end of the track to its start.
+type trackRoute struct {
+	points  []*models.GPSLocation
+	offsets []time.Duration
+	at      time.Duration
+}
+
+// newTrackRoute starts the replay start into the track, counted forth and back
+func newTrackRoute(points []*models.GPSLocation, start time.Duration) *trackRoute {
+	offsets := make([]time.Duration, len(points))
+	for i, point := range points {
+		offsets[i] = point.FixTime.Sub(points[0].FixTime)
+	}
+
+	return &trackRoute{points: points, offsets: offsets, at: start}
+}
+
+func (r *trackRoute) duration() time.Duration {
+	return r.offsets[len(r.offsets)-1]
+}
+
+func (r *trackRoute) Advance(elapsed time.Duration) (float64, float64) {
+	r.at += elapsed
+
+	total := r.duration()
+	if total <= 0 {
+		return r.points[0].Latitude, r.points[0].Longitude
+	}
+
+	at := r.at % (2 * total)
+	if at > total {
+		at = 2*total - at
+	}
+
+	i := 1
+	for i < len(r.offsets)-1 && r.offsets[i] < at {
+		i++
+	}
+
+	from, to := r.points[i-1], r.points[i]
+	span := r.offsets[i] - r.offsets[i-1]
+	if span <= 0 {
+		return to.Latitude, to.Longitude
+	}
+
+	ratio := float64(at-r.offsets[i-1]) / float64(span)
+	return from.Latitude + (to.Latitude-from.Latitude)*ratio, from.Longitude + (to.Longitude-from.Longitude)*ratio
+}
+
+const (
+	walkRadiusMeters = 5000
+	walkMinSpeed     = 2.0
+	walkMaxSpeed     = 20.0
+	// Pauses make the trip detection find stops in generated tracks
+	walkPauseChance = 0.03
+	walkMinPause    = 6 * time.Minute
+	walkMaxPause    = 20 * time.Minute
+)
+
+// walkRoute wanders around its start at road speeds, turning a little at every step and pausing now and then.
+type walkRoute struct {
+	rng       *rand.Rand
+	start     models.GPSLocation
+	position  models.GPSLocation
+	heading   float64
+	speed     float64
+	pauseLeft time.Duration
+}
+
+func newWalkRoute(rng *rand.Rand, latitude float64, longitude float64) *walkRoute {
+	start := models.GPSLocation{Latitude: latitude, Longitude: longitude}
+	// Spread the devices around the start point so they don't overlap
+	position := move(start, rng.Float64()*360, rng.Float64()*walkRadiusMeters/2)
+
+	return &walkRoute{
+		rng:      rng,
+		start:    start,
+		position: position,
+		heading:  rng.Float64() * 360,
+		speed:    walkMinSpeed + rng.Float64()*(walkMaxSpeed-walkMinSpeed),
+	}
+}
+
+func (r *walkRoute) Advance(elapsed time.Duration) (float64, float64) {
+	if r.pauseLeft > 0 {
+		r.pauseLeft -= elapsed
+		return r.position.Latitude, r.position.Longitude
+	}
+
+	if r.rng.Float64() < walkPauseChance {
+		r.pauseLeft = walkMinPause + time.Duration(r.rng.Int63n(int64(walkMaxPause-walkMinPause)))
+		return r.position.Latitude, r.position.Longitude
+	}
+
+	if tracks.Distance(&r.position, &r.start) > walkRadiusMeters {
+		r.heading = bearing(r.position, r.start)
+	} else {
+		r.heading = math.Mod(r.heading+r.rng.NormFloat64()*20+360, 360)
+	}
+
+	r.speed = math.Min(walkMaxSpeed, math.Max(walkMinSpeed, r.speed+r.rng.NormFloat64()))
+	r.position = move(r.position, r.heading, r.speed*elapsed.Seconds())
+
+	return r.position.Latitude, r.position.Longitude
+}
+
+const earthRadiusMeters = 6371000
+
+// move returns the point distance meters away from location in the direction of heading, in degrees from north
+func move(location models.GPSLocation, heading float64, distance float64) models.GPSLocation {
+	lat := location.Latitude * math.Pi / 180
+	lon := location.Longitude * math.Pi / 180
+	angle := distance / earthRadiusMeters
+	theta := heading * math.Pi / 180
+
+	lat2 := math.Asin(math.Sin(lat)*math.Cos(angle) + math.Cos(lat)*math.Sin(angle)*math.Cos(theta))
+	lon2 := lon + math.Atan2(math.Sin(theta)*math.Sin(angle)*math.Cos(lat), math.Cos(angle)-math.Sin(lat)*math.Sin(lat2))
+
+	return models.GPSLocation{Latitude: lat2 * 180 / math.Pi, Longitude: lon2 * 180 / math.Pi}
+}
+
+// bearing returns the initial heading from a to b in degrees from north
+func bearing(a models.GPSLocation, b models.GPSLocation) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
@@ -0,0 +1,247 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/ingestion"
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/tracks"
+	"gorm.io/gorm"
+)
+
+// Options of a simulation, read from the SIMULATOR_* environment variables
+type Options struct {
+	// Devices is the number of virtual devices, they are created on the first run and reused afterwards
+	Devices int
+	// Tracks are GPX, KML or GeoJSON files replayed by the devices in turn, devices walk randomly without tracks
+	Tracks []string
+	// Speed plays the routes faster than real time, fix times stay real so the rest of the system sees live devices
+	Speed float64
+	// Interval between two reports of a device
+	Interval time.Duration
+	// OwnerEmail is the user owning the virtual devices, nobody sees them without one
+	OwnerEmail string
+	// Latitude and Longitude are where random walks start
+	Latitude  float64
+	Longitude float64
+	Seed      int64
+}
+
+func LoadOptions() (*Options, error) {
+	options := &Options{
+		Devices:    5,
+		Speed:      1,
+		Interval:   10 * time.Second,
+		OwnerEmail: os.Getenv("SIMULATOR_OWNER_EMAIL"),
+		Latitude:   50.4501,
+		Longitude:  30.5234,
+		Seed:       time.Now().UnixNano(),
+	}
+
+	var err error
+	if value := os.Getenv("SIMULATOR_DEVICES"); value != "" {
+		if options.Devices, err = strconv.Atoi(value); err != nil || options.Devices < 1 {
+			return nil, errors.New("SIMULATOR_DEVICES must be a positive number")
+		}
+	}
+
+	if value := os.Getenv("SIMULATOR_TRACKS"); value != "" {
+		for _, path := range strings.Split(value, ",") {
+			options.Tracks = append(options.Tracks, strings.TrimSpace(path))
+		}
+	}
+
+	if value := os.Getenv("SIMULATOR_SPEED"); value != "" {
+		if options.Speed, err = strconv.ParseFloat(value, 64); err != nil || options.Speed <= 0 {
+			return nil, errors.New("SIMULATOR_SPEED must be a positive number")
+		}
+	}
+
+	if value := os.Getenv("SIMULATOR_INTERVAL_SECONDS"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 1 {
+			return nil, errors.New("SIMULATOR_INTERVAL_SECONDS must be a positive number")
+		}
+		options.Interval = time.Duration(seconds) * time.Second
+	}
+
+	if value := os.Getenv("SIMULATOR_START"); value != "" {
+		if _, err := fmt.Sscanf(value, "%f,%f", &options.Latitude, &options.Longitude); err != nil {
+			return nil, errors.New("SIMULATOR_START must be latitude,longitude")
+		}
+	}
+
+	if value := os.Getenv("SIMULATOR_SEED"); value != "" {
+		if options.Seed, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, errors.New("SIMULATOR_SEED must be a number")
+		}
+	}
+
+	return options, nil
+}
+
+// Run moves the virtual devices until ctx is cancelled. Their positions go through the same ingestion as
+// real devices, so filters, health, geofence alerts and streams see them like any tracker.
+func Run(ctx context.Context, db *gorm.DB, options *Options) error {
+	trackPoints, err := loadTracks(options.Tracks)
+	if err != nil {
+		return err
+	}
+
+	devices, err := ensureDevices(db, options)
+	if err != nil {
+		return err
+	}
+
+	rng := rand.New(rand.NewSource(options.Seed))
+
+	var wg sync.WaitGroup
+	for i, device := range devices {
+		var r route
+		if len(trackPoints) > 0 {
+			// Devices sharing a track are spread over the forth and back of it
+			sharing := (len(devices) + len(trackPoints) - 1) / len(trackPoints)
+			points := trackPoints[i%len(trackPoints)]
+			offset := 2 * points[len(points)-1].FixTime.Sub(points[0].FixTime) * time.Duration(i/len(trackPoints)) / time.Duration(sharing)
+			r = newTrackRoute(points, offset)
+		} else {
+			r = newWalkRoute(rand.New(rand.NewSource(rng.Int63())), options.Latitude, options.Longitude)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			simulate(ctx, db, device, r, options)
+		}()
+	}
+
+	log.Println("Simulating", len(devices), "devices every", options.Interval, "at", options.Speed, "times real speed")
+
+	wg.Wait()
+	return nil
+}
+
+func loadTracks(paths []string) ([][]*models.GPSLocation, error) {
+	var loaded [][]*models.GPSLocation
+
+	for _, path := range paths {
+		format, err := tracks.FormatFromFilename(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		points, err := tracks.Parse(format, file)
+		file.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		if len(points) == 0 {
+			return nil, fmt.Errorf("%s: track has no points", path)
+		}
+
+		loaded = append(loaded, points)
+	}
+
+	return loaded, nil
+}
+
+// ensureDevices loads the virtual devices, creating the missing ones. They are found again by their IMEI.
+func ensureDevices(db *gorm.DB, options *Options) ([]*models.GPSDevice, error) {
+	var owner *models.User
+	if options.OwnerEmail != "" {
+		owner = &models.User{}
+		if err := db.Where("email = ?", options.OwnerEmail).First(owner).Error; err != nil {
+			return nil, fmt.Errorf("simulator owner %s: %w", options.OwnerEmail, err)
+		}
+	} else {
+		log.Println("SIMULATOR_OWNER_EMAIL is not set, the virtual devices won't be visible to anyone")
+	}
+
+	devices := make([]*models.GPSDevice, options.Devices)
+	for i := range devices {
+		imei := fmt.Sprintf("SIM%012d", i+1)
+		name := fmt.Sprintf("Simulator %d", i+1)
+		tracking := true
+
+		device := &models.GPSDevice{Imei: &imei, Name: &name, Tracking: &tracking, Provider: models.ProviderSimulator}
+		if owner != nil {
+			device.CreatedByID = &owner.ID
+		}
+
+		if err := db.Where("imei = ?", imei).Attrs(device).FirstOrCreate(device).Error; err != nil {
+			return nil, err
+		}
+
+		devices[i] = device
+	}
+
+	return devices, nil
+}
+
+// simulate reports the position of the device on its route every interval, with a battery slowly draining
+// and recharging so the health checks have something to report
+func simulate(ctx context.Context, db *gorm.DB, device *models.GPSDevice, r route, options *Options) {
+	ticker := time.NewTicker(options.Interval)
+	defer ticker.Stop()
+
+	battery := 100.0
+	satellites := 9
+	accuracy := 8.0
+	var previous *models.GPSLocation
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		latitude, longitude := r.Advance(time.Duration(float64(options.Interval) * options.Speed))
+		location := &models.GPSLocation{
+			Latitude:   latitude,
+			Longitude:  longitude,
+			FixTime:    time.Now().UTC(),
+			Satellites: &satellites,
+			Accuracy:   &accuracy,
+		}
+
+		batteryLevel := battery
+		location.Battery = &batteryLevel
+		if battery -= 0.1; battery < 10 {
+			battery = 100
+		}
+
+		if previous != nil {
+			distance := tracks.Distance(previous, location)
+			speed := distance / location.FixTime.Sub(previous.FixTime).Seconds() * 3.6
+			location.Speed = &speed
+
+			if distance > 0 {
+				course := bearing(*previous, *location)
+				location.Course = &course
+			}
+		}
+
+		previous = location
+
+		if err := ingestion.StoreLocations(db, device, []*models.GPSLocation{location}); err != nil {
+			log.Println("Failed to store simulated location of device", device.ID, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/config"
+	"github.com/Hodik/geo-tracker-be/coordination"
+	"github.com/Hodik/geo-tracker-be/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Locations are inserted with the time of the ingesting process, a transaction still in flight at the end of
+// a run may commit rows slightly older than the run. They are picked up by the next run thanks to the overlap.
+const statsRollupOverlap = time.Minute
+
+func ScheduleStats(ctx context.Context, db *gorm.DB, replica *coordination.Replica) {
+	for {
+		conf := config.GetConfig(db)
+
+		// Rollups are rewritten as a whole day, two replicas would only do the same work twice
+		if replica.IsLeader() {
+			if err := RollupStats(db); err != nil {
+				log.Default().Println("Failed to roll up device statistics", err)
+			}
+		}
+
+		if !sleepContext(ctx, time.Duration(conf.StatsJobMinutes)*time.Minute) {
+			return
+		}
+	}
+}
+
+func RollupStats(db *gorm.DB) error {
+	var devices []models.GPSDevice
+	if err := db.Find(&devices).Error; err != nil {
+		return err
+	}
+
+	for _, device := range devices {
+		if err := RollupDeviceStats(db, &device); err != nil {
+			log.Default().Println("Failed to roll up statistics of device", device.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// RollupDeviceStats computes again every day that received locations since the last run, from the first
+// of them to the latest fix of the device. Time between two fixes is moving time when the device went at
+// least MovingSpeedKmh, idle time otherwise, and counts for the day of the later fix. Only moving
+// segments add to the distance, so GPS drift while parked doesn't run up the odometer.
+func RollupDeviceStats(db *gorm.DB, device *models.GPSDevice) error {
+	conf := config.GetConfig(db)
+	started := time.Now().UTC()
+
+	var state models.DeviceStatsRollup
+	if err := db.Where("device_id = ?", device.ID).FirstOrInit(&state, models.DeviceStatsRollup{DeviceID: device.ID}).Error; err != nil {
+		return err
+	}
+
+	var earliest *time.Time
+	if err := db.Model(&models.GPSLocation{}).
+		Where("device_id = ? AND created_at > ? AND filter_reason IS NULL", device.ID, state.ProcessedUntil.Add(-statsRollupOverlap)).
+		Select("MIN(fix_time)").Scan(&earliest).Error; err != nil {
+		return err
+	}
+
+	if earliest == nil {
+		return nil
+	}
+
+	location := device.Location()
+	local := earliest.In(location)
+	since := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+
+	var days []models.DeviceDailyStats
+	if err := db.Raw(`
+		SELECT
+			(fix_time AT TIME ZONE @timezone)::date AS day,
+			COALESCE(SUM(distance) FILTER (WHERE moving), 0) AS distance_meters,
+			COALESCE(SUM(elapsed) FILTER (WHERE moving AND elapsed <= @max_gap), 0)::bigint AS moving_seconds,
+			COALESCE(SUM(elapsed) FILTER (WHERE NOT moving AND elapsed <= @max_gap), 0)::bigint AS idle_seconds,
+			COALESCE(MAX(speed) FILTER (WHERE moving), 0) AS max_speed
+		FROM (
+			SELECT fix_time, distance, elapsed, speed, speed >= @moving_speed AS moving
+			FROM (
+				SELECT fix_time, distance, elapsed,
+					COALESCE(reported_speed, distance / NULLIF(elapsed, 0) * 3.6, 0) AS speed
+				FROM (
+					SELECT fix_time, speed AS reported_speed,
+						ST_Distance(point, LAG(point) OVER segment) AS distance,
+						EXTRACT(EPOCH FROM fix_time - LAG(fix_time) OVER segment) AS elapsed
+					FROM (
+						SELECT fix_time, speed, ST_MakePoint(longitude, latitude)::geography AS point
+						FROM gps_locations
+						WHERE device_id = @device AND filter_reason IS NULL AND deleted_at IS NULL
+							AND fix_time >= COALESCE((
+								SELECT MAX(fix_time) FROM gps_locations
+								WHERE device_id = @device AND filter_reason IS NULL AND deleted_at IS NULL AND fix_time < @since
+							), @since)
+					) locations
+					WINDOW segment AS (ORDER BY fix_time)
+				) segments
+			) speeds
+			WHERE fix_time >= @since AND elapsed IS NOT NULL
+		) classified
+		GROUP BY day
+	`, map[string]interface{}{
+		"device":       device.ID,
+		"timezone":     location.String(),
+		"since":        since,
+		"max_gap":      conf.StatsMaxGapMinutes * 60,
+		"moving_speed": conf.MovingSpeedKmh,
+	}).Scan(&days).Error; err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for i := range days {
+			days[i].DeviceID = device.ID
+		}
+
+		// Days are only ever overwritten, days in between whose locations expired keep their figures
+		if len(days) > 0 {
+			if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&days).Error; err != nil {
+				return err
+			}
+		}
+
+		state.ProcessedUntil = started
+		return tx.Save(&state).Error
+	})
+}
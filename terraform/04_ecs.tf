@@ -18,9 +18,22 @@ locals {
     AWS_REGION            = var.region
     auth0_audience        = var.auth0_audience
     auth0_domain          = var.auth0_domain
+
+    credentials_primary_key = var.credentials_primary_key
+    credentials_keys_arn    = aws_secretsmanager_secret.credentials_keys.arn
   }
 }
 
+# Keys encrypting provider credentials at rest, injected into the containers by ECS
+resource "aws_secretsmanager_secret" "credentials_keys" {
+  name = "${var.project_name}-credentials-keys"
+}
+
+resource "aws_secretsmanager_secret_version" "credentials_keys" {
+  secret_id     = aws_secretsmanager_secret.credentials_keys.id
+  secret_string = var.credentials_keys
+}
+
 # Backend web task definition and service
 resource "aws_ecs_task_definition" "api_task" {
   network_mode             = "awsvpc"
@@ -225,6 +238,22 @@ resource "aws_iam_role_policy_attachment" "ecs-task-execution-role-policy-attach
   policy_arn = "arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy"
 }
 
+resource "aws_iam_role_policy" "task_execution_secrets" {
+  name = "ecs-task-execution-secrets"
+  role = aws_iam_role.task_execution.id
+
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [
+      {
+        Action   = ["secretsmanager:GetSecretValue"]
+        Effect   = "Allow"
+        Resource = [aws_secretsmanager_secret.credentials_keys.arn]
+      },
+    ]
+  })
+}
+
 # Cloudwatch Logs
 resource "aws_cloudwatch_log_group" "backend" {
   name              = var.project_name
@@ -22,7 +22,12 @@
       {"name": "AUTH0_AUDIENCE", "value": "${auth0_audience}"},
       {"name": "AWS_ACCESS_KEY_ID", "value": "${AWS_ACCESS_KEY_ID}"},
       {"name": "AWS_SECRET_ACCESS_KEY", "value": "${AWS_SECRET_ACCESS_KEY}"},
-      {"name": "AWS_REGION", "value": "${AWS_REGION}"}
+      {"name": "AWS_REGION", "value": "${AWS_REGION}"},
+      {"name": "CREDENTIALS_PRIMARY_KEY", "value": "${credentials_primary_key}"},
+      {"name": "CREDENTIALS_REQUIRED", "value": "true"}
+    ],
+    "secrets": [
+      {"name": "CREDENTIALS_KEYS", "valueFrom": "${credentials_keys_arn}"}
     ],
     "logConfiguration": {
       "logDriver": "awslogs",
@@ -69,3 +69,15 @@ variable "media_s3" {
   description = "S3 bucket for media"
   default     = "geotracker-media"
 }
+
+
+variable "credentials_keys" {
+  description = "Keys encrypting provider credentials, comma separated id:base64key pairs of 32 byte keys"
+  sensitive   = true
+}
+
+
+variable "credentials_primary_key" {
+  description = "Id of the key encrypting new credentials, optional with a single key"
+  default     = ""
+}
@@ -0,0 +1,245 @@
+package tracks
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+)
+
+type Format string
+
+const (
+	FormatJSON        Format = "json"
+	FormatGeoJSON     Format = "geojson"
+	FormatGeoJSONLine Format = "geojson_line"
+	FormatGPX         Format = "gpx"
+	FormatKML         Format = "kml"
+)
+
+var ErrUnknownFormat = errors.New("unknown track format")
+
+// Iterator calls fn for every location of the track in chronological order.
+// Exporters may iterate more than once, so every call must start from the first location and return the same ones.
+type Iterator func(fn func(location *models.GPSLocation) error) error
+
+func ValidateExportFormat(format string) error {
+	switch Format(format) {
+	case FormatJSON, FormatGeoJSON, FormatGeoJSONLine, FormatGPX, FormatKML:
+		return nil
+	}
+	return ErrUnknownFormat
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatGeoJSON, FormatGeoJSONLine:
+		return "application/geo+json"
+	case FormatGPX:
+		return "application/gpx+xml"
+	case FormatKML:
+		return "application/vnd.google-earth.kml+xml"
+	default:
+		return "application/json"
+	}
+}
+
+func (f Format) Extension() string {
+	switch f {
+	case FormatGeoJSON, FormatGeoJSONLine:
+		return "geojson"
+	default:
+		return string(f)
+	}
+}
+
+// Export streams the track into w without loading it into memory.
+func Export(format Format, w io.Writer, name string, each Iterator) error {
+	switch format {
+	case FormatGeoJSON:
+		return exportGeoJSONPoints(w, each)
+	case FormatGeoJSONLine:
+		return exportGeoJSONLine(w, name, each)
+	case FormatGPX:
+		return exportGPX(w, name, each)
+	case FormatKML:
+		return exportKML(w, name, each)
+	default:
+		return ErrUnknownFormat
+	}
+}
+
+type geoJSONGeometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+type geoJSONFeature struct {
+	Type       string              `json:"type"`
+	Geometry   geoJSONGeometry     `json:"geometry"`
+	Properties *models.GPSLocation `json:"properties"`
+}
+
+func exportGeoJSONPoints(w io.Writer, each Iterator) error {
+	if _, err := io.WriteString(w, `{"type":"FeatureCollection","features":[`); err != nil {
+		return err
+	}
+
+	first := true
+	err := each(func(location *models.GPSLocation) error {
+		if !first {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		first = false
+
+		feature := geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   geoJSONGeometry{Type: "Point", Coordinates: coordinates(location)},
+			Properties: location,
+		}
+
+		b, err := json.Marshal(feature)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(b)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]}")
+	return err
+}
+
+func exportGeoJSONLine(w io.Writer, name string, each Iterator) error {
+	properties, err := json.Marshal(map[string]string{"name": name})
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, `{"type":"Feature","properties":%s,"geometry":{"type":"LineString","coordinates":[`, properties); err != nil {
+		return err
+	}
+
+	first := true
+	err = each(func(location *models.GPSLocation) error {
+		if !first {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		first = false
+
+		b, err := json.Marshal(coordinates(location))
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(b)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]}}")
+	return err
+}
+
+type gpxPoint struct {
+	XMLName    xml.Name `xml:"trkpt"`
+	Latitude   float64  `xml:"lat,attr"`
+	Longitude  float64  `xml:"lon,attr"`
+	Elevation  *float64 `xml:"ele,omitempty"`
+	Time       string   `xml:"time"`
+	Satellites *int     `xml:"sat,omitempty"`
+}
+
+func exportGPX(w io.Writer, name string, each Iterator) error {
+	if _, err := io.WriteString(w, xml.Header+`<gpx version="1.1" creator="geo-tracker" xmlns="http://www.topografix.com/GPX/1/1"><trk><name>`); err != nil {
+		return err
+	}
+	if err := xml.EscapeText(w, []byte(name)); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "</name><trkseg>"); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	err := each(func(location *models.GPSLocation) error {
+		return encoder.Encode(gpxPoint{
+			Latitude:   location.Latitude,
+			Longitude:  location.Longitude,
+			Elevation:  location.Altitude,
+			Time:       location.FixTime.UTC().Format(time.RFC3339),
+			Satellites: location.Satellites,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if err := encoder.Flush(); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "</trkseg></trk></gpx>")
+	return err
+}
+
+// exportKML writes a gx:Track, which lists all timestamps before all coordinates and needs two passes.
+func exportKML(w io.Writer, name string, each Iterator) error {
+	if _, err := io.WriteString(w, xml.Header+`<kml xmlns="http://www.opengis.net/kml/2.2" xmlns:gx="http://www.google.com/kml/ext/2.2"><Document><Placemark><name>`); err != nil {
+		return err
+	}
+	if err := xml.EscapeText(w, []byte(name)); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "</name><gx:Track>"); err != nil {
+		return err
+	}
+
+	err := each(func(location *models.GPSLocation) error {
+		_, err := fmt.Fprintf(w, "<when>%s</when>", location.FixTime.UTC().Format(time.RFC3339))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = each(func(location *models.GPSLocation) error {
+		altitude := 0.0
+		if location.Altitude != nil {
+			altitude = *location.Altitude
+		}
+		_, err := fmt.Fprintf(w, "<gx:coord>%s %s %s</gx:coord>", formatFloat(location.Longitude), formatFloat(location.Latitude), formatFloat(altitude))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "</gx:Track></Placemark></Document></kml>")
+	return err
+}
+
+func coordinates(location *models.GPSLocation) []float64 {
+	if location.Altitude != nil {
+		return []float64{location.Longitude, location.Latitude, *location.Altitude}
+	}
+	return []float64{location.Longitude, location.Latitude}
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
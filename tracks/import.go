@@ -0,0 +1,413 @@
+package tracks
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+)
+
+var ErrMissingTimestamps = errors.New("track has no timestamps")
+
+// FormatFromFilename picks the import format from the file extension.
+func FormatFromFilename(filename string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".gpx":
+		return FormatGPX, nil
+	case ".kml":
+		return FormatKML, nil
+	case ".geojson", ".json":
+		return FormatGeoJSON, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// Parse reads every point of a GPX, KML or GeoJSON track. Points must be timestamped, a single untimed
+// point fails the whole track with ErrMissingTimestamps rather than importing part of it.
+func Parse(format Format, r io.Reader) ([]*models.GPSLocation, error) {
+	switch format {
+	case FormatGPX:
+		return parseGPX(r)
+	case FormatKML:
+		return parseKML(r)
+	case FormatGeoJSON, FormatGeoJSONLine:
+		return parseGeoJSON(r)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+type gpxTrackPoint struct {
+	Latitude   float64  `xml:"lat,attr"`
+	Longitude  float64  `xml:"lon,attr"`
+	Elevation  *float64 `xml:"ele"`
+	Time       string   `xml:"time"`
+	Satellites *int     `xml:"sat"`
+}
+
+func parseGPX(r io.Reader) ([]*models.GPSLocation, error) {
+	var locations []*models.GPSLocation
+
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "trkpt" {
+			continue
+		}
+
+		var point gpxTrackPoint
+		if err := decoder.DecodeElement(&point, &start); err != nil {
+			return nil, err
+		}
+
+		fixTime, err := parseTime(point.Time)
+		if err != nil {
+			return nil, err
+		}
+
+		locations = append(locations, &models.GPSLocation{
+			Latitude:   point.Latitude,
+			Longitude:  point.Longitude,
+			FixTime:    fixTime,
+			Altitude:   point.Elevation,
+			Satellites: point.Satellites,
+		})
+	}
+
+	return locations, nil
+}
+
+type kmlTrack struct {
+	When  []string `xml:"when"`
+	Coord []string `xml:"coord"`
+}
+
+type kmlLineString struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlPlacemark struct {
+	TimeStampWhen    string          `xml:"TimeStamp>when"`
+	TimeSpanBegin    string          `xml:"TimeSpan>begin"`
+	TimeSpanEnd      string          `xml:"TimeSpan>end"`
+	LineStrings      []kmlLineString `xml:"LineString"`
+	MultiLineStrings []kmlLineString `xml:"MultiGeometry>LineString"`
+	Tracks           []kmlTrack      `xml:"Track"`
+	MultiTracks      []kmlTrack      `xml:"MultiTrack>Track"`
+}
+
+func parseKML(r io.Reader) ([]*models.GPSLocation, error) {
+	var locations []*models.GPSLocation
+
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Placemark" {
+			continue
+		}
+
+		var placemark kmlPlacemark
+		if err := decoder.DecodeElement(&placemark, &start); err != nil {
+			return nil, err
+		}
+
+		for _, track := range append(placemark.Tracks, placemark.MultiTracks...) {
+			parsed, err := parseKMLTrack(track)
+			if err != nil {
+				return nil, err
+			}
+			locations = append(locations, parsed...)
+		}
+
+		for _, line := range append(placemark.LineStrings, placemark.MultiLineStrings...) {
+			parsed, err := parseKMLLineString(line, &placemark)
+			if err != nil {
+				return nil, err
+			}
+			locations = append(locations, parsed...)
+		}
+	}
+
+	return locations, nil
+}
+
+func parseKMLTrack(track kmlTrack) ([]*models.GPSLocation, error) {
+	if len(track.When) != len(track.Coord) {
+		return nil, errors.New("gx:Track has a different number of when and gx:coord elements")
+	}
+
+	locations := make([]*models.GPSLocation, 0, len(track.Coord))
+	for i, coord := range track.Coord {
+		values := strings.Fields(coord)
+		location, err := kmlLocation(values)
+		if err != nil {
+			return nil, err
+		}
+
+		if location.FixTime, err = parseTime(track.When[i]); err != nil {
+			return nil, err
+		}
+
+		locations = append(locations, location)
+	}
+
+	return locations, nil
+}
+
+// parseKMLLineString spreads the points of a plain LineString evenly over the TimeSpan of its placemark,
+// or pins them all to its TimeStamp. LineStrings don't time their points, the fix times are estimates.
+func parseKMLLineString(line kmlLineString, placemark *kmlPlacemark) ([]*models.GPSLocation, error) {
+	tuples := strings.Fields(line.Coordinates)
+	if len(tuples) == 0 {
+		return nil, nil
+	}
+
+	var begin, end time.Time
+	var err error
+
+	switch {
+	case placemark.TimeSpanBegin != "" && placemark.TimeSpanEnd != "":
+		if begin, err = parseTime(placemark.TimeSpanBegin); err != nil {
+			return nil, err
+		}
+		if end, err = parseTime(placemark.TimeSpanEnd); err != nil {
+			return nil, err
+		}
+	case placemark.TimeStampWhen != "":
+		if begin, err = parseTime(placemark.TimeStampWhen); err != nil {
+			return nil, err
+		}
+		end = begin
+	default:
+		return nil, ErrMissingTimestamps
+	}
+
+	step := time.Duration(0)
+	if len(tuples) > 1 {
+		step = end.Sub(begin) / time.Duration(len(tuples)-1)
+	}
+
+	locations := make([]*models.GPSLocation, 0, len(tuples))
+	for i, tuple := range tuples {
+		location, err := kmlLocation(strings.Split(tuple, ","))
+		if err != nil {
+			return nil, err
+		}
+
+		location.FixTime = begin.Add(step * time.Duration(i))
+		locations = append(locations, location)
+	}
+
+	return locations, nil
+}
+
+func kmlLocation(values []string) (*models.GPSLocation, error) {
+	if len(values) < 2 {
+		return nil, fmt.Errorf("invalid KML coordinate: %v", values)
+	}
+
+	longitude, err := strconv.ParseFloat(values[0], 64)
+	if err != nil {
+		return nil, err
+	}
+
+	latitude, err := strconv.ParseFloat(values[1], 64)
+	if err != nil {
+		return nil, err
+	}
+
+	location := &models.GPSLocation{Latitude: latitude, Longitude: longitude}
+
+	if len(values) > 2 {
+		if altitude, err := strconv.ParseFloat(values[2], 64); err == nil {
+			location.Altitude = &altitude
+		}
+	}
+
+	return location, nil
+}
+
+type geoJSONObject struct {
+	Type       string                 `json:"type"`
+	Features   []geoJSONObject        `json:"features"`
+	Geometry   *geoJSONObject         `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+	// Coordinates depth depends on the geometry type
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+func parseGeoJSON(r io.Reader) ([]*models.GPSLocation, error) {
+	var root geoJSONObject
+	if err := json.NewDecoder(r).Decode(&root); err != nil {
+		return nil, err
+	}
+
+	switch root.Type {
+	case "FeatureCollection":
+		var locations []*models.GPSLocation
+		for _, feature := range root.Features {
+			parsed, err := parseGeoJSONFeature(&feature)
+			if err != nil {
+				return nil, err
+			}
+			locations = append(locations, parsed...)
+		}
+		return locations, nil
+	case "Feature":
+		return parseGeoJSONFeature(&root)
+	default:
+		return nil, errors.New("GeoJSON must be a Feature or FeatureCollection")
+	}
+}
+
+// parseGeoJSONFeature reads timestamps from the time property of points and the coordTimes
+// property of lines, the convention used by togeojson and most fitness exports.
+func parseGeoJSONFeature(feature *geoJSONObject) ([]*models.GPSLocation, error) {
+	if feature.Geometry == nil {
+		return nil, nil
+	}
+
+	switch feature.Geometry.Type {
+	case "Point":
+		var coordinates []float64
+		if err := json.Unmarshal(feature.Geometry.Coordinates, &coordinates); err != nil {
+			return nil, err
+		}
+
+		timestamp := firstString(feature.Properties, "fix_time", "time", "timestamp")
+		if timestamp == "" {
+			return nil, ErrMissingTimestamps
+		}
+
+		location, err := geoJSONLocation(coordinates, timestamp)
+		if err != nil {
+			return nil, err
+		}
+		return []*models.GPSLocation{location}, nil
+
+	case "LineString":
+		var coordinates [][]float64
+		if err := json.Unmarshal(feature.Geometry.Coordinates, &coordinates); err != nil {
+			return nil, err
+		}
+		return geoJSONLine(coordinates, coordTimes(feature.Properties["coordTimes"]))
+
+	case "MultiLineString":
+		var lines [][][]float64
+		if err := json.Unmarshal(feature.Geometry.Coordinates, &lines); err != nil {
+			return nil, err
+		}
+
+		times, _ := feature.Properties["coordTimes"].([]interface{})
+		if len(times) != len(lines) {
+			return nil, ErrMissingTimestamps
+		}
+
+		var locations []*models.GPSLocation
+		for i, line := range lines {
+			parsed, err := geoJSONLine(line, coordTimes(times[i]))
+			if err != nil {
+				return nil, err
+			}
+			locations = append(locations, parsed...)
+		}
+		return locations, nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func geoJSONLine(coordinates [][]float64, times []string) ([]*models.GPSLocation, error) {
+	if len(times) != len(coordinates) {
+		return nil, ErrMissingTimestamps
+	}
+
+	locations := make([]*models.GPSLocation, 0, len(coordinates))
+	for i, position := range coordinates {
+		location, err := geoJSONLocation(position, times[i])
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, location)
+	}
+
+	return locations, nil
+}
+
+func geoJSONLocation(position []float64, timestamp string) (*models.GPSLocation, error) {
+	if len(position) < 2 {
+		return nil, errors.New("invalid GeoJSON position")
+	}
+
+	fixTime, err := parseTime(timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	location := &models.GPSLocation{Longitude: position[0], Latitude: position[1], FixTime: fixTime}
+	if len(position) > 2 {
+		altitude := position[2]
+		location.Altitude = &altitude
+	}
+
+	return location, nil
+}
+
+func coordTimes(value interface{}) []string {
+	items, _ := value.([]interface{})
+	times := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			times = append(times, s)
+		}
+	}
+	return times
+}
+
+func firstString(properties map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := properties[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+func parseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, ErrMissingTimestamps
+	}
+
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp '%s'", value)
+	}
+
+	return parsed.UTC(), nil
+}
@@ -0,0 +1,79 @@
+package tracks
+
+import (
+	"math"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+)
+
+type SegmentKind string
+
+const (
+	SegmentTrip SegmentKind = "trip"
+	SegmentStop SegmentKind = "stop"
+)
+
+// Segment is a run of locations, From and To are inclusive indexes into the segmented slice.
+type Segment struct {
+	Kind SegmentKind
+	From int
+	To   int
+	// Closed segments are followed by another segment and will not change when more points arrive
+	Closed bool
+}
+
+type SegmentOptions struct {
+	StopRadiusMeters float64
+	StopMinDuration  time.Duration
+}
+
+const earthRadiusMeters = 6371000
+
+// Distance returns the great circle distance between two locations in meters.
+func Distance(a, b *models.GPSLocation) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(h))
+}
+
+// Split splits chronologically ordered locations into stops, where the device stayed within
+// StopRadiusMeters for at least StopMinDuration, and trips connecting them.
+// A trip starts at the last point of the previous stop and ends at the first point of the next one.
+func Split(locations []*models.GPSLocation, options SegmentOptions) []Segment {
+	var segments []Segment
+	moveStart := -1
+
+	for i := 0; i < len(locations); {
+		j := i + 1
+		for j < len(locations) && Distance(locations[i], locations[j]) <= options.StopRadiusMeters {
+			j++
+		}
+
+		if locations[j-1].FixTime.Sub(locations[i].FixTime) < options.StopMinDuration {
+			if moveStart < 0 {
+				moveStart = i
+			}
+			i++
+			continue
+		}
+
+		if moveStart >= 0 {
+			from := moveStart
+			if from > 0 {
+				from--
+			}
+			segments = append(segments, Segment{Kind: SegmentTrip, From: from, To: i, Closed: true})
+			moveStart = -1
+		}
+
+		segments = append(segments, Segment{Kind: SegmentStop, From: i, To: j - 1, Closed: j < len(locations)})
+		i = j
+	}
+
+	return segments
+}
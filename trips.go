@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/config"
+	"github.com/Hodik/geo-tracker-be/coordination"
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/tracks"
+	"gorm.io/gorm"
+)
+
+// Upper bound of locations loaded per device and run, long histories catch up over several runs
+const tripSegmentationBatchSize = 10000
+
+func ScheduleTripSegmentation(ctx context.Context, db *gorm.DB, replica *coordination.Replica) {
+	for {
+		conf := config.GetConfig(db)
+
+		// Two replicas segmenting the same device would store its trips twice
+		if replica.IsLeader() {
+			if err := SegmentTrips(db); err != nil {
+				log.Default().Println("Failed to segment trips", err)
+			}
+		}
+
+		if !sleepContext(ctx, time.Duration(conf.TripJobMinutes)*time.Minute) {
+			return
+		}
+	}
+}
+
+func SegmentTrips(db *gorm.DB) error {
+	var devices []models.GPSDevice
+	if err := db.Find(&devices).Error; err != nil {
+		return err
+	}
+
+	for _, device := range devices {
+		if err := SegmentDeviceTrips(db, &device); err != nil {
+			log.Default().Println("Failed to segment trips of device", device.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// SegmentDeviceTrips stores the trips and stops closed since the last run. The trailing open
+// segment is not stored, it is computed again once more locations arrive.
+func SegmentDeviceTrips(db *gorm.DB, device *models.GPSDevice) error {
+	conf := config.GetConfig(db)
+
+	var state models.TripSegmentation
+	if err := db.Where("device_id = ?", device.ID).FirstOrInit(&state, models.TripSegmentation{DeviceID: device.ID}).Error; err != nil {
+		return err
+	}
+
+	var locations []*models.GPSLocation
+	if err := db.Where("device_id = ? AND fix_time >= ? AND filter_reason IS NULL", device.ID, state.ProcessedUntil).
+		Order("fix_time, id").Limit(tripSegmentationBatchSize).Find(&locations).Error; err != nil {
+		return err
+	}
+
+	if len(locations) < 2 {
+		return nil
+	}
+
+	segments := tracks.Split(locations, tracks.SegmentOptions{
+		StopRadiusMeters: conf.StopRadiusMeters,
+		StopMinDuration:  time.Duration(conf.StopMinMinutes) * time.Minute,
+	})
+
+	// A full batch without a closed segment would be loaded again forever. Without any stop it is cut into a
+	// trip, a device parked for the whole batch gets its stop closed and extended by the next batch.
+	if len(locations) == tripSegmentationBatchSize {
+		if len(segments) == 0 {
+			segments = []tracks.Segment{{Kind: tracks.SegmentTrip, From: 0, To: len(locations) - 1, Closed: true}}
+		} else if segments[0].Kind == tracks.SegmentStop && !segments[0].Closed {
+			segments[0].Closed = true
+		}
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, segment := range segments {
+			if !segment.Closed {
+				break
+			}
+
+			points := locations[segment.From : segment.To+1]
+
+			switch segment.Kind {
+			case tracks.SegmentTrip:
+				trip, err := buildTrip(tx, device, points)
+				if err != nil {
+					return err
+				}
+
+				// Short hops are GPS drift around a stop rather than actual movement
+				if trip.DistanceMeters >= conf.TripMinDistanceMeters {
+					if err := tx.Create(trip).Error; err != nil {
+						return err
+					}
+				}
+			case tracks.SegmentStop:
+				if err := storeStop(tx, buildStop(device, points)); err != nil {
+					return err
+				}
+			}
+
+			state.ProcessedUntil = locations[segment.To].FixTime
+		}
+
+		return tx.Save(&state).Error
+	})
+}
+
+func buildTrip(db *gorm.DB, device *models.GPSDevice, points []*models.GPSLocation) (*models.Trip, error) {
+	start := points[0]
+	end := points[len(points)-1]
+
+	var distance float64
+	if err := db.Raw(`
+		SELECT COALESCE(ST_Length(ST_MakeLine(ST_SetSRID(ST_MakePoint(longitude, latitude), 4326) ORDER BY fix_time)::geography), 0)
+		FROM gps_locations
+		WHERE device_id = ? AND fix_time BETWEEN ? AND ? AND filter_reason IS NULL AND deleted_at IS NULL
+	`, device.ID, start.FixTime, end.FixTime).Scan(&distance).Error; err != nil {
+		return nil, err
+	}
+
+	duration := end.FixTime.Sub(start.FixTime)
+
+	var maxSpeed float64
+	for i, point := range points {
+		speed := 0.0
+		if point.Speed != nil {
+			speed = *point.Speed
+		} else if i > 0 {
+			if elapsed := point.FixTime.Sub(points[i-1].FixTime).Seconds(); elapsed > 0 {
+				speed = tracks.Distance(points[i-1], point) / elapsed * 3.6
+			}
+		}
+
+		if speed > maxSpeed {
+			maxSpeed = speed
+		}
+	}
+
+	var avgSpeed float64
+	if duration > 0 {
+		avgSpeed = distance / duration.Seconds() * 3.6
+	}
+
+	return &models.Trip{
+		DeviceID:        device.ID,
+		StartTime:       start.FixTime,
+		EndTime:         end.FixTime,
+		StartLatitude:   start.Latitude,
+		StartLongitude:  start.Longitude,
+		EndLatitude:     end.Latitude,
+		EndLongitude:    end.Longitude,
+		DistanceMeters:  distance,
+		DurationSeconds: int64(duration.Seconds()),
+		MaxSpeed:        maxSpeed,
+		AvgSpeed:        avgSpeed,
+	}, nil
+}
+
+// storeStop saves a stop, extending the stop it continues when that one was cut at the end of a full batch
+func storeStop(db *gorm.DB, stop *models.Stop) error {
+	var previous models.Stop
+	result := db.Where("device_id = ? AND end_time = ?", stop.DeviceID, stop.StartTime).Limit(1).Find(&previous)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return db.Create(stop).Error
+	}
+
+	previous.EndTime = stop.EndTime
+	previous.DurationSeconds = int64(stop.EndTime.Sub(previous.StartTime).Seconds())
+	return db.Save(&previous).Error
+}
+
+func buildStop(device *models.GPSDevice, points []*models.GPSLocation) *models.Stop {
+	var latitude, longitude float64
+	for _, point := range points {
+		latitude += point.Latitude
+		longitude += point.Longitude
+	}
+
+	start := points[0].FixTime
+	end := points[len(points)-1].FixTime
+
+	return &models.Stop{
+		DeviceID:        device.ID,
+		StartTime:       start,
+		EndTime:         end,
+		Latitude:        latitude / float64(len(points)),
+		Longitude:       longitude / float64(len(points)),
+		DurationSeconds: int64(end.Sub(start).Seconds()),
+	}
+}
@@ -0,0 +1,170 @@
+package views
+
+import (
+	"errors"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/schemas"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetProviderAccounts godoc
+// @Summary Get provider accounts
+// @Description Get the tracker vendor accounts of the currently authenticated user
+// @Tags provider-accounts
+// @Produce json
+// @Success 200 {array} models.ProviderAccount
+// @Failure 500 {object} schemas.Error
+// @Router /api/provider-accounts [get]
+func GetProviderAccounts(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	db := c.MustGet("db").(*gorm.DB)
+
+	var accounts []models.ProviderAccount
+	if err := db.Where("created_by_id = ?", user.ID).Order("created_at").Find(&accounts).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, accounts)
+}
+
+// CreateProviderAccount godoc
+// @Summary Create a provider account
+// @Description Store the login of a tracker vendor account owning several trackers. Devices linked to it are polled with one session, matched by IMEI.
+// @Tags provider-accounts
+// @Accept json
+// @Produce json
+// @Param createProviderAccount body schemas.CreateProviderAccount true "Create provider account"
+// @Success 201 {object} models.ProviderAccount
+// @Failure 400 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/provider-accounts [post]
+func CreateProviderAccount(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	db := c.MustGet("db").(*gorm.DB)
+
+	var schema schemas.CreateProviderAccount
+
+	if err := c.ShouldBindJSON(&schema); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := schema.ToProviderAccount(user)
+
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var count int64
+	if err := db.Model(&models.ProviderAccount{}).Where("provider = ? AND username = ?", account.Provider, account.Username).Count(&count).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	if count > 0 {
+		c.JSON(400, gin.H{"error": "provider account already exists"})
+		return
+	}
+
+	if err := db.Create(account).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(201, account)
+}
+
+// UpdateProviderAccount godoc
+// @Summary Update a provider account
+// @Description Change the password of a tracker vendor account, the next poll logs in again
+// @Tags provider-accounts
+// @Accept json
+// @Produce json
+// @Param id path string true "Provider account ID"
+// @Param updateProviderAccount body schemas.UpdateProviderAccount true "Update provider account"
+// @Success 200 {object} models.ProviderAccount
+// @Failure 400 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/provider-accounts/{id} [patch]
+func UpdateProviderAccount(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	db := c.MustGet("db").(*gorm.DB)
+
+	var schema schemas.UpdateProviderAccount
+
+	if err := c.ShouldBindJSON(&schema); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var account models.ProviderAccount
+	result := db.Where("id = ? AND created_by_id = ?", c.Param("id"), user.ID).First(&account)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "provider account not found"})
+		return
+	}
+
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	schema.ToProviderAccount(&account)
+
+	if err := db.Save(&account).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, account)
+}
+
+// DeleteProviderAccount godoc
+// @Summary Delete a provider account
+// @Description Delete a tracker vendor account. Its devices are kept but no longer polled until linked again.
+// @Tags provider-accounts
+// @Produce json
+// @Param id path string true "Provider account ID"
+// @Success 204
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/provider-accounts/{id} [delete]
+func DeleteProviderAccount(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	db := c.MustGet("db").(*gorm.DB)
+
+	var account models.ProviderAccount
+	result := db.Where("id = ? AND created_by_id = ?", c.Param("id"), user.ID).First(&account)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "provider account not found"})
+		return
+	}
+
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.GPSDevice{}).Where("account_id = ?", account.ID).Update("account_id", nil).Error; err != nil {
+			return err
+		}
+
+		// Unscoped so the username can be added again
+		return tx.Unscoped().Delete(&account).Error
+	})
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(204)
+}
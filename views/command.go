@@ -0,0 +1,149 @@
+package views
+
+import (
+	"errors"
+
+	"github.com/Hodik/geo-tracker-be/commands"
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/schemas"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetGPSDeviceCommandCatalog godoc
+// @Summary Get commands supported by a GPS device
+// @Description Get the commands the model of a device understands
+// @Tags devices
+// @Produce json
+// @Param id path string true "Device ID"
+// @Success 200 {array} commands.Definition
+// @Failure 404 {object} schemas.Error
+// @Router /api/devices/{id}/commands/catalog [get]
+func GetGPSDeviceCommandCatalog(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	device, err := GetGPSDeviceFromParam(c, db, models.DeviceRoleViewer)
+
+	if err != nil {
+		c.JSON(DeviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, commands.Catalog(device.Provider))
+}
+
+// CreateGPSDeviceCommand godoc
+// @Summary Send a command to a GPS device
+// @Description Queue a command for a device. It is sent over SMS by the worker and retried until the device acknowledges it or attempts are exhausted.
+// @Tags devices
+// @Accept json
+// @Produce json
+// @Param id path string true "Device ID"
+// @Param createDeviceCommand body schemas.CreateDeviceCommand true "Create device command"
+// @Success 202 {object} models.DeviceCommand
+// @Failure 400 {object} schemas.Error
+// @Failure 403 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Failure 503 {object} schemas.Error
+// @Router /api/devices/{id}/commands [post]
+func CreateGPSDeviceCommand(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	db := c.MustGet("db").(*gorm.DB)
+
+	device, err := GetGPSDeviceFromParam(c, db, models.DeviceRoleManager)
+
+	if err != nil {
+		c.JSON(DeviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var schema schemas.CreateDeviceCommand
+
+	if err := c.ShouldBindJSON(&schema); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	definition, err := commands.Find(device.Provider, schema.Name)
+
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if device.Number == nil {
+		c.JSON(400, gin.H{"error": "device has no phone number"})
+		return
+	}
+
+	command, err := commands.Queue(db, device, definition, user)
+
+	if errors.Is(err, commands.ErrSMSNotConfigured) {
+		c.JSON(503, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(202, command)
+}
+
+// GetGPSDeviceCommands godoc
+// @Summary Get commands sent to a GPS device
+// @Description Get every command queued for a device with its delivery status, latest first
+// @Tags devices
+// @Produce json
+// @Param id path string true "Device ID"
+// @Param from query string false "Start of the range (RFC 3339)"
+// @Param to query string false "End of the range (RFC 3339)"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Number of items per page"
+// @Success 200 {object} schemas.Paginated{items=[]models.DeviceCommand}
+// @Failure 400 {object} schemas.Error
+// @Failure 403 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/devices/{id}/commands [get]
+func GetGPSDeviceCommands(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	device, err := GetGPSDeviceFromParam(c, db, models.DeviceRoleManager)
+
+	if err != nil {
+		c.JSON(DeviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var query schemas.TimeRangeQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	deviceCommands := query.Apply(db.Model(&models.DeviceCommand{}).Where("device_id = ?", device.ID), "created_at", "created_at")
+
+	var total int64
+	if err := deviceCommands.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	var items []models.DeviceCommand
+	if err := deviceCommands.Limit(query.PageSize).Offset((query.Page - 1) * query.PageSize).Find(&items).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	paginated := schemas.Paginated{Page: query.Page, PageSize: query.PageSize, Total: int(total), Items: items}
+	c.JSON(200, paginated)
+}
@@ -35,7 +35,7 @@ func CreateCommunity(c *gin.Context) {
 		return
 	}
 
-	comminuty, err := schema.ToCommunity(db)
+	comminuty, err := schema.ToCommunity(db, user)
 
 	if err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
@@ -172,6 +172,16 @@ func GetCommunity(c *gin.Context) {
 		return
 	}
 
+	// Tracking a device doesn't share it, members only see the devices shared with them
+	visible, err := FilterVisibleDevices(db, user, community.TrackingDevices)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	community.TrackingDevices = visible
+
 	c.JSON(200, community)
 }
 
@@ -187,7 +197,7 @@ func GetCommunities(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
 
 	var communities []models.Community
-	result := db.Select("communities.created_at, communities.deleted_at, communities.updated_at, communities.id, communities.name, communities.description, type, appears_in_search, include_external_events, allow_read_only_members_add_events").Where("deleted_at IS NULL AND appears_in_search = true").Find(&communities)
+	result := db.Select("communities.created_at, communities.deleted_at, communities.updated_at, communities.id, communities.name, communities.description, type, appears_in_search, include_external_events, allow_read_only_members_add_events, location_retention_days").Where("deleted_at IS NULL AND appears_in_search = true").Find(&communities)
 
 	if result.Error != nil {
 		c.JSON(500, gin.H{"error": result.Error.Error()})
@@ -705,6 +715,19 @@ func CommunityTrackDevice(c *gin.Context) {
 		return
 	}
 
+	// Tracking only notifies members the device is shared with, but it may not be used to probe devices
+	canView, err := device.HasRole(db, reqUser, models.DeviceRoleViewer)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !canView {
+		c.JSON(404, gin.H{"error": "device not found"})
+		return
+	}
+
 	if community.IsDeviceTracked(&device) {
 		c.JSON(400, gin.H{"error": "community is already tracking this device"})
 		return
@@ -1,34 +1,43 @@
 package views
 
 import (
-	"errors"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
 
+	"github.com/Hodik/geo-tracker-be/ingestion"
 	"github.com/Hodik/geo-tracker-be/models"
 	"github.com/Hodik/geo-tracker-be/schemas"
+	"github.com/Hodik/geo-tracker-be/tracks"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// Full history is served by GetGPSDeviceLocations, the device itself only carries the latest fixes
+const latestLocationsLimit = 5
+
 // GetGPSDevices godoc
 // @Summary Get all GPS devices
-// @Description Get a list of all GPS devices for the currently authenticated user
+// @Description Get the GPS devices the currently authenticated user owns or that are shared with them
 // @Tags devices
 // @Produce json
-// @Success 200 {array} models.GPSDevice
+// @Success 200 {array} schemas.GPSDeviceResponse
 // @Failure 500 {object} schemas.Error
 // @Router /api/devices [get]
 func GetGPSDevices(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
 
 	var devices []models.GPSDevice
-	result := db.Omit("password").Find(&devices)
+	result := db.Omit("password").Where("id IN (?)", models.DevicesWithRole(db, user, models.DeviceRoleViewer)).Find(&devices)
 
 	if result.Error != nil {
 		c.JSON(500, gin.H{"error": result.Error.Error()})
 		return
 	}
 
-	c.JSON(200, devices)
+	c.JSON(200, schemas.ToGPSDeviceResponses(devices))
 }
 
 // CreateGPSDevice godoc
@@ -38,7 +47,7 @@ func GetGPSDevices(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param createGPSDevice body schemas.CreateGPSDevice true "Create GPS device"
-// @Success 201 {object} models.GPSDevice
+// @Success 201 {object} schemas.GPSDeviceResponse
 // @Failure 400 {object} schemas.Error
 // @Failure 500 {object} schemas.Error
 // @Router /api/devices [post]
@@ -53,15 +62,26 @@ func CreateGPSDevice(c *gin.Context) {
 		return
 	}
 
-	deviceModel := device.ToGPSDevice(user)
-	result := db.Create(&deviceModel)
+	deviceModel, err := device.ToGPSDevice(user)
+
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ValidateDeviceAccount(db, user, deviceModel); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := db.Create(deviceModel)
 
 	if result.Error != nil {
 		c.JSON(500, gin.H{"error": result.Error.Error()})
 		return
 	}
 
-	c.JSON(201, deviceModel)
+	c.JSON(201, schemas.ToGPSDeviceResponse(deviceModel, true))
 }
 
 // UpdateGPSDevice godoc
@@ -72,13 +92,15 @@ func CreateGPSDevice(c *gin.Context) {
 // @Produce json
 // @Param id path string true "Device ID"
 // @Param updateGPSDevice body schemas.UpdateGPSDevice true "Update GPS device"
-// @Success 200 {object} models.GPSDevice
+// @Success 200 {object} schemas.GPSDeviceResponse
 // @Failure 400 {object} schemas.Error
+// @Failure 403 {object} schemas.Error
 // @Failure 404 {object} schemas.Error
 // @Failure 500 {object} schemas.Error
 // @Router /api/devices/{id} [patch]
 func UpdateGPSDevice(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
 
 	var device schemas.UpdateGPSDevice
 
@@ -87,52 +109,383 @@ func UpdateGPSDevice(c *gin.Context) {
 		return
 	}
 
-	id := c.Param("id")
+	deviceModel, err := GetGPSDeviceFromParam(c, db, models.DeviceRoleManager)
 
-	var deviceModel models.GPSDevice
-	result := db.Where("id = ?", id).First(&deviceModel)
+	if err != nil {
+		c.JSON(DeviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
 
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		c.JSON(404, gin.H{"error": "device not found"})
+	if err := device.ToGPSDevice(deviceModel); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	device.ToGPSDevice(&deviceModel)
+	if device.AccountID != nil {
+		if err := ValidateDeviceAccount(db, user, deviceModel); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
-	result = db.Save(&deviceModel)
+	result := db.Save(deviceModel)
 
 	if result.Error != nil {
 		c.JSON(500, gin.H{"error": result.Error.Error()})
 		return
 	}
 
-	c.JSON(200, deviceModel)
+	c.JSON(200, schemas.ToGPSDeviceResponse(deviceModel, true))
 }
 
 // GetGPSDevice godoc
 // @Summary Get a GPS device by ID
-// @Description Get details of a GPS device by its ID, including its locations
+// @Description Get details of a GPS device by its ID, including its latest locations
 // @Tags devices
 // @Produce json
 // @Param id path string true "Device ID"
-// @Success 200 {object} models.GPSDevice
+// @Success 200 {object} schemas.GPSDeviceResponse
 // @Failure 404 {object} schemas.Error
 // @Failure 500 {object} schemas.Error
 // @Router /api/devices/{id} [get]
 func GetGPSDevice(c *gin.Context) {
 	db := c.MustGet("db").(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
+
+	device, err := GetGPSDeviceFromParam(c, db, models.DeviceRoleViewer)
+
+	if err != nil {
+		c.JSON(DeviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.Where("device_id = ? AND filter_reason IS NULL", device.ID).
+		Order("fix_time DESC").Limit(latestLocationsLimit).Find(&device.Locations).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	canManage, err := device.HasRole(db, user, models.DeviceRoleManager)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, schemas.ToGPSDeviceResponse(device, canManage))
+}
+
+// GetGPSDeviceLocations godoc
+// @Summary Get location history of a GPS device
+// @Description Get locations of a device in a time range. JSON responses are paginated with a cursor, other formats stream the whole range as a file.
+// @Tags devices
+// @Produce json
+// @Produce application/geo+json
+// @Produce application/gpx+xml
+// @Produce application/vnd.google-earth.kml+xml
+// @Param id path string true "Device ID"
+// @Param from query string false "Start of the range (RFC 3339)"
+// @Param to query string false "End of the range (RFC 3339)"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Param limit query int false "Number of locations per page, JSON only"
+// @Param format query string false "json, geojson, geojson_line, gpx or kml"
+// @Param include_filtered query bool false "Include points rejected by the ingestion filters"
+// @Success 200 {object} schemas.LocationPage
+// @Failure 400 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/devices/{id}/locations [get]
+func GetGPSDeviceLocations(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	device, err := GetGPSDeviceFromParam(c, db, models.DeviceRoleViewer)
+
+	if err != nil {
+		c.JSON(DeviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var query schemas.LocationHistoryQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	history, err := query.Apply(db.Model(&models.GPSLocation{}).Where("device_id = ?", device.ID))
+
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := tracks.Format(query.Format)
+
+	if format == tracks.FormatJSON {
+		var locations []models.GPSLocation
+		if err := history.Limit(query.Limit + 1).Find(&locations).Error; err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(200, schemas.ToLocationPage(locations, query.Limit))
+		return
+	}
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, device.ID, format.Extension()))
+	c.Status(200)
+
+	// KML and GPX read the history twice, a snapshot keeps locations ingested in between out of the second pass
+	err = db.Transaction(func(tx *gorm.DB) error {
+		snapshot, err := query.Apply(tx.Model(&models.GPSLocation{}).Where("device_id = ?", device.ID))
+		if err != nil {
+			return err
+		}
+
+		return tracks.Export(format, c.Writer, device.DisplayName(), func(fn func(location *models.GPSLocation) error) error {
+			rows, err := snapshot.Session(&gorm.Session{}).Rows()
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var location models.GPSLocation
+				if err := tx.ScanRows(rows, &location); err != nil {
+					return err
+				}
+
+				if err := fn(&location); err != nil {
+					return err
+				}
+			}
+
+			return rows.Err()
+		})
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+
+	// Headers are already sent at this point, the client sees a truncated file
+	if err != nil {
+		log.Println("Failed to export locations of device", device.ID, err)
+	}
+}
+
+// Uploaded tracks are parsed in memory, keep them reasonably small
+const maxTrackUploadSize = 32 << 20
+
+// ImportGPSDeviceTrack godoc
+// @Summary Import a track into a GPS device
+// @Description Import historical locations from a GPX, KML or GeoJSON file. Every point needs a timestamp, a file with untimed points is rejected as a whole. Points already present in the device history are skipped.
+// @Description Plain KML LineStrings carry no time per point, their points are spread evenly over the TimeSpan of the placemark, or all pinned to its TimeStamp, so the stored fix times are estimates.
+// @Tags devices
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Device ID"
+// @Param file formData file true "GPX, KML or GeoJSON file"
+// @Success 201 {object} schemas.TrackImportResult
+// @Failure 400 {object} schemas.Error
+// @Failure 403 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/devices/{id}/import [post]
+func ImportGPSDeviceTrack(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	device, err := GetGPSDeviceFromParam(c, db, models.DeviceRoleManager)
 
-	id := c.Param("id")
+	if err != nil {
+		c.JSON(DeviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
 
-	var device models.GPSDevice
-	result := db.Preload("Locations", func(db *gorm.DB) *gorm.DB {
-		return db.Order("created_at DESC")
-	}).Where("id = ?", id).First(&device)
+	file, err := FormFile(c, maxTrackUploadSize)
 
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		c.JSON(404, gin.H{"error": "device not found"})
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(200, device)
+	format, err := tracks.FormatFromFilename(file.Filename)
+
+	if err != nil {
+		c.JSON(400, gin.H{"error": "only .gpx, .kml and .geojson files are supported"})
+		return
+	}
+
+	openedFile, err := file.Open()
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	defer openedFile.Close()
+
+	locations, err := tracks.Parse(format, openedFile)
+
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	imported, err := ingestion.ImportLocations(db, device, locations)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(201, schemas.TrackImportResult{Imported: imported.Imported, Duplicates: imported.Duplicates, Invalid: imported.Invalid})
+}
+
+// GetGPSDeviceTrips godoc
+// @Summary Get trips of a GPS device
+// @Description Get trips detected in the location history of a device, latest first. Trips are detected periodically and only once the device stopped.
+// @Tags devices
+// @Produce json
+// @Param id path string true "Device ID"
+// @Param from query string false "Start of the range (RFC 3339)"
+// @Param to query string false "End of the range (RFC 3339)"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Number of items per page"
+// @Success 200 {object} schemas.Paginated{items=[]models.Trip}
+// @Failure 400 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/devices/{id}/trips [get]
+func GetGPSDeviceTrips(c *gin.Context) {
+	var trips []models.Trip
+	getGPSDeviceSegments(c, &models.Trip{}, &trips)
+}
+
+// GetGPSDeviceStops godoc
+// @Summary Get stops of a GPS device
+// @Description Get stops detected in the location history of a device, latest first. A stop is reported once the device left it.
+// @Tags devices
+// @Produce json
+// @Param id path string true "Device ID"
+// @Param from query string false "Start of the range (RFC 3339)"
+// @Param to query string false "End of the range (RFC 3339)"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Number of items per page"
+// @Success 200 {object} schemas.Paginated{items=[]models.Stop}
+// @Failure 400 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/devices/{id}/stops [get]
+func GetGPSDeviceStops(c *gin.Context) {
+	var stops []models.Stop
+	getGPSDeviceSegments(c, &models.Stop{}, &stops)
+}
+
+func getGPSDeviceSegments(c *gin.Context, model interface{}, items interface{}) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	device, err := GetGPSDeviceFromParam(c, db, models.DeviceRoleViewer)
+
+	if err != nil {
+		c.JSON(DeviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var query schemas.TimeRangeQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	segments := query.Apply(db.Model(model).Where("device_id = ?", device.ID), "start_time", "end_time")
+
+	var total int64
+	if err := segments.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := segments.Limit(query.PageSize).Offset((query.Page - 1) * query.PageSize).Find(items).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	paginated := schemas.Paginated{Page: query.Page, PageSize: query.PageSize, Total: int(total), Items: items}
+	c.JSON(200, paginated)
+}
+
+// GetGPSDeviceStats godoc
+// @Summary Get distance and time statistics of a GPS device
+// @Description Get the distance travelled, moving time and idle time of a device per day, week or month, with its odometer. Days follow the timezone of the device and are rolled up periodically, so the latest movements may be missing.
+// @Tags devices
+// @Produce json
+// @Produce text/csv
+// @Param id path string true "Device ID"
+// @Param from query string false "First day of the range (YYYY-MM-DD), 30 days before to by default"
+// @Param to query string false "Last day of the range (YYYY-MM-DD), today by default"
+// @Param granularity query string false "day, week or month"
+// @Param format query string false "json or csv"
+// @Success 200 {object} schemas.DeviceStats
+// @Failure 400 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/devices/{id}/stats [get]
+func GetGPSDeviceStats(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	device, err := GetGPSDeviceFromParam(c, db, models.DeviceRoleViewer)
+
+	if err != nil {
+		c.JSON(DeviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var query schemas.DeviceStatsQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Validate(time.Now().In(device.Location())); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var days []models.DeviceDailyStats
+	if err := db.Where("device_id = ? AND day BETWEEN ? AND ?", device.ID, query.From.Format(time.DateOnly), query.To.Format(time.DateOnly)).
+		Order("day").Find(&days).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	var odometer float64
+	if err := db.Model(&models.DeviceDailyStats{}).Where("device_id = ? AND day <= ?", device.ID, query.To.Format(time.DateOnly)).
+		Select("COALESCE(SUM(distance_meters), 0)").Scan(&odometer).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats := schemas.ToDeviceStats(device, &query, days, odometer)
+
+	if query.Format == schemas.StatsFormatJSON {
+		c.JSON(200, stats)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-stats.csv"`, device.ID))
+	c.Status(200)
+
+	if err := stats.WriteCSV(c.Writer); err != nil {
+		log.Println("Failed to export statistics of device", device.ID, err)
+	}
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/Hodik/geo-tracker-be/config"
 	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/realtime"
 	"github.com/Hodik/geo-tracker-be/schemas"
 	"github.com/Hodik/geo-tracker-be/storage"
 	"github.com/gin-gonic/gin"
@@ -99,6 +100,7 @@ func GetEvent(c *gin.Context) {
 // @Param createEvent body schemas.CreateEvent true "Create event"
 // @Success 201 {object} models.Event
 // @Failure 400 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
 // @Failure 500 {object} schemas.Error
 // @Router /api/events [post]
 func CreateEvent(c *gin.Context) {
@@ -112,6 +114,11 @@ func CreateEvent(c *gin.Context) {
 	}
 
 	event, err := schema.ToEvent(db, user)
+	if errors.Is(err, schemas.ErrEventDeviceNotFound) {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+
 	if err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
@@ -122,6 +129,8 @@ func CreateEvent(c *gin.Context) {
 		return
 	}
 
+	realtime.PublishEvent(db, event)
+
 	c.JSON(201, event)
 }
 
@@ -255,7 +264,8 @@ func PostComment(c *gin.Context) {
 	}
 
 	var event models.Event
-	result := db.Where("id = ?", eventID).First(&event)
+	// Communities decide who receives the comment on their streams
+	result := db.Preload("Communities").Where("id = ?", eventID).First(&event)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		c.JSON(404, gin.H{"error": "Event not found"})
@@ -284,6 +294,8 @@ func PostComment(c *gin.Context) {
 		return
 	}
 
+	realtime.PublishComment(db, &event, comment)
+
 	c.JSON(201, comment)
 }
 
@@ -0,0 +1,218 @@
+package views
+
+import (
+	"errors"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/schemas"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func preloadGeofenceArea(db *gorm.DB) *gorm.DB {
+	return db.Select("id, ST_AsText(polygon_area) as polygon_area, created_at, updated_at, deleted_at, latitude, longitude, radius_in_meters")
+}
+
+// GetGPSDeviceGeofences godoc
+// @Summary Get geofence rules of a GPS device
+// @Description Get the areas of interest watched for a device together with their enter, exit or dwell triggers
+// @Tags devices
+// @Produce json
+// @Param id path string true "Device ID"
+// @Success 200 {array} models.GeofenceRule
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/devices/{id}/geofences [get]
+func GetGPSDeviceGeofences(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	device, err := GetGPSDeviceFromParam(c, db, models.DeviceRoleViewer)
+
+	if err != nil {
+		c.JSON(DeviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var rules []models.GeofenceRule
+	if err := db.Preload("AreaOfInterest", preloadGeofenceArea).Where("device_id = ?", device.ID).Order("created_at").Find(&rules).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, rules)
+}
+
+// CreateGPSDeviceGeofence godoc
+// @Summary Create a geofence rule for a GPS device
+// @Description Watch an existing area of interest, or a new one, for a device. Everyone tracking the device is notified when the rule triggers.
+// @Tags devices
+// @Accept json
+// @Produce json
+// @Param id path string true "Device ID"
+// @Param createGeofenceRule body schemas.CreateGeofenceRule true "Create geofence rule"
+// @Success 201 {object} models.GeofenceRule
+// @Failure 400 {object} schemas.Error
+// @Failure 403 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/devices/{id}/geofences [post]
+func CreateGPSDeviceGeofence(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	db := c.MustGet("db").(*gorm.DB)
+
+	device, err := GetGPSDeviceFromParam(c, db, models.DeviceRoleManager)
+
+	if err != nil {
+		c.JSON(DeviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var schema schemas.CreateGeofenceRule
+
+	if err := c.ShouldBindJSON(&schema); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := schema.ToGeofenceRule(device, user)
+
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if rule.AreaOfInterest == nil {
+		var count int64
+		if err := db.Model(&models.AreaOfInterest{}).Where("id = ? AND id IN (?)", rule.AreaOfInterestID, models.AreasOfInterestVisibleTo(db, user)).Count(&count).Error; err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		if count == 0 {
+			c.JSON(404, gin.H{"error": "area of interest not found"})
+			return
+		}
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if rule.AreaOfInterest != nil {
+			if err := rule.AreaOfInterest.Create(tx); err != nil {
+				return err
+			}
+
+			rule.AreaOfInterestID = rule.AreaOfInterest.ID
+		}
+
+		return tx.Omit("AreaOfInterest").Create(rule).Error
+	})
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.Preload("AreaOfInterest", preloadGeofenceArea).First(rule, "id = ?", rule.ID).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(201, rule)
+}
+
+// DeleteGPSDeviceGeofence godoc
+// @Summary Delete a geofence rule of a GPS device
+// @Description Stop watching an area of interest for a device. The area of interest itself is kept.
+// @Tags devices
+// @Produce json
+// @Param id path string true "Device ID"
+// @Param geofence_id path string true "Geofence rule ID"
+// @Success 204
+// @Failure 403 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/devices/{id}/geofences/{geofence_id} [delete]
+func DeleteGPSDeviceGeofence(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	device, err := GetGPSDeviceFromParam(c, db, models.DeviceRoleManager)
+
+	if err != nil {
+		c.JSON(DeviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var rule models.GeofenceRule
+	result := db.Where("id = ? AND device_id = ?", c.Param("geofence_id"), device.ID).First(&rule)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "geofence rule not found"})
+		return
+	}
+
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if err := db.Delete(&rule).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(204)
+}
+
+// GetGPSDeviceGeofenceTransitions godoc
+// @Summary Get geofence transitions of a GPS device
+// @Description Get the enter, exit and dwell transitions recorded for a device, latest first
+// @Tags devices
+// @Produce json
+// @Param id path string true "Device ID"
+// @Param from query string false "Start of the range (RFC 3339)"
+// @Param to query string false "End of the range (RFC 3339)"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Number of items per page"
+// @Success 200 {object} schemas.Paginated{items=[]models.GeofenceTransition}
+// @Failure 400 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/devices/{id}/geofence-transitions [get]
+func GetGPSDeviceGeofenceTransitions(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	device, err := GetGPSDeviceFromParam(c, db, models.DeviceRoleViewer)
+
+	if err != nil {
+		c.JSON(DeviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var query schemas.TimeRangeQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	transitions := query.Apply(db.Model(&models.GeofenceTransition{}).Where("device_id = ?", device.ID), "fix_time", "fix_time")
+
+	var total int64
+	if err := transitions.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	var items []models.GeofenceTransition
+	if err := transitions.Limit(query.PageSize).Offset((query.Page - 1) * query.PageSize).Find(&items).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	paginated := schemas.Paginated{Page: query.Page, PageSize: query.PageSize, Total: int(total), Items: items}
+	c.JSON(200, paginated)
+}
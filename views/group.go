@@ -0,0 +1,603 @@
+package views
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/commands"
+	"github.com/Hodik/geo-tracker-be/config"
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/schemas"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetDeviceGroups godoc
+// @Summary Get device groups
+// @Description Get the device groups of the currently authenticated user
+// @Tags device-groups
+// @Produce json
+// @Success 200 {array} schemas.DeviceGroupResponse
+// @Failure 500 {object} schemas.Error
+// @Router /api/device-groups [get]
+func GetDeviceGroups(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
+
+	var groups []models.DeviceGroup
+	if err := db.Where("owner_id = ?", user.ID).Order("name").Find(&groups).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	responses := make([]schemas.DeviceGroupResponse, len(groups))
+	for i := range groups {
+		devices, err := GroupDevicesWithRole(db, user, &groups[i], models.DeviceRoleViewer)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		responses[i] = schemas.ToDeviceGroupResponse(&groups[i], deviceIDs(devices))
+	}
+
+	c.JSON(200, responses)
+}
+
+// CreateDeviceGroup godoc
+// @Summary Create a device group
+// @Description Create a device group owned by the currently authenticated user, optionally with its first devices
+// @Tags device-groups
+// @Accept json
+// @Produce json
+// @Param createDeviceGroup body schemas.CreateDeviceGroup true "Create device group"
+// @Success 201 {object} schemas.DeviceGroupResponse
+// @Failure 400 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/device-groups [post]
+func CreateDeviceGroup(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
+
+	var schema schemas.CreateDeviceGroup
+
+	if err := c.ShouldBindJSON(&schema); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := schema.ToDeviceGroup(user)
+
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	devices, err := findVisibleDevices(db, user, schema.DeviceIDs)
+
+	if errors.Is(err, errDeviceNotFound) {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	group.Devices = devices
+
+	if err := db.Create(group).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	ids := make([]uuid.UUID, len(devices))
+	for i, device := range devices {
+		ids[i] = device.ID
+	}
+
+	c.JSON(201, schemas.ToDeviceGroupResponse(group, ids))
+}
+
+// GetDeviceGroup godoc
+// @Summary Get a device group by ID
+// @Description Get a device group with the IDs of its devices
+// @Tags device-groups
+// @Produce json
+// @Param id path string true "Device group ID"
+// @Success 200 {object} schemas.DeviceGroupResponse
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/device-groups/{id} [get]
+func GetDeviceGroup(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
+
+	group, err := GetDeviceGroupFromParam(c, db)
+
+	if err != nil {
+		c.JSON(DeviceGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	devices, err := GroupDevicesWithRole(db, user, group, models.DeviceRoleViewer)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, schemas.ToDeviceGroupResponse(group, deviceIDs(devices)))
+}
+
+// UpdateDeviceGroup godoc
+// @Summary Update a device group
+// @Description Rename a device group or change its description
+// @Tags device-groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Device group ID"
+// @Param updateDeviceGroup body schemas.UpdateDeviceGroup true "Update device group"
+// @Success 200 {object} schemas.DeviceGroupResponse
+// @Failure 400 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/device-groups/{id} [patch]
+func UpdateDeviceGroup(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
+
+	group, err := GetDeviceGroupFromParam(c, db)
+
+	if err != nil {
+		c.JSON(DeviceGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var schema schemas.UpdateDeviceGroup
+
+	if err := c.ShouldBindJSON(&schema); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := schema.ToDeviceGroup(group); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.Save(group).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	devices, err := GroupDevicesWithRole(db, user, group, models.DeviceRoleViewer)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, schemas.ToDeviceGroupResponse(group, deviceIDs(devices)))
+}
+
+// DeleteDeviceGroup godoc
+// @Summary Delete a device group
+// @Description Delete a device group, its devices are left untouched
+// @Tags device-groups
+// @Produce json
+// @Param id path string true "Device group ID"
+// @Success 204
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/device-groups/{id} [delete]
+func DeleteDeviceGroup(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	group, err := GetDeviceGroupFromParam(c, db)
+
+	if err != nil {
+		c.JSON(DeviceGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(group).Association("Devices").Clear(); err != nil {
+			return err
+		}
+
+		return tx.Delete(group).Error
+	})
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(204)
+}
+
+// AddDeviceGroupDevices godoc
+// @Summary Add devices to a device group
+// @Description Add devices the user can see to a device group, devices already in the group are ignored
+// @Tags device-groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Device group ID"
+// @Param groupDevices body schemas.GroupDevices true "Devices to add"
+// @Success 200 {object} schemas.DeviceGroupResponse
+// @Failure 400 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/device-groups/{id}/add-devices [post]
+func AddDeviceGroupDevices(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
+
+	group, err := GetDeviceGroupFromParam(c, db)
+
+	if err != nil {
+		c.JSON(DeviceGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var schema schemas.GroupDevices
+
+	if err := c.ShouldBindJSON(&schema); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := schema.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	devices, err := findVisibleDevices(db, user, schema.DeviceIDs)
+
+	if errors.Is(err, errDeviceNotFound) {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	var members int64
+	if err := db.Table("device_group_members").Where("device_group_id = ? AND gps_device_id NOT IN ?", group.ID, schema.DeviceIDs).
+		Count(&members).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	if int(members)+len(devices) > schemas.MaxGroupDevices {
+		c.JSON(400, gin.H{"error": "too many devices in a group"})
+		return
+	}
+
+	if err := db.Model(group).Association("Devices").Append(devices); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	groupDevices, err := GroupDevicesWithRole(db, user, group, models.DeviceRoleViewer)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, schemas.ToDeviceGroupResponse(group, deviceIDs(groupDevices)))
+}
+
+// RemoveDeviceGroupDevices godoc
+// @Summary Remove devices from a device group
+// @Description Remove devices from a device group, devices not in the group are ignored
+// @Tags device-groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Device group ID"
+// @Param groupDevices body schemas.GroupDevices true "Devices to remove"
+// @Success 200 {object} schemas.DeviceGroupResponse
+// @Failure 400 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/device-groups/{id}/remove-devices [post]
+func RemoveDeviceGroupDevices(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
+
+	group, err := GetDeviceGroupFromParam(c, db)
+
+	if err != nil {
+		c.JSON(DeviceGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var schema schemas.GroupDevices
+
+	if err := c.ShouldBindJSON(&schema); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := schema.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Members may have become invisible to the user, they can still be removed
+	if err := db.Exec("DELETE FROM device_group_members WHERE device_group_id = ? AND gps_device_id IN ?", group.ID, schema.DeviceIDs).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	devices, err := GroupDevicesWithRole(db, user, group, models.DeviceRoleViewer)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, schemas.ToDeviceGroupResponse(group, deviceIDs(devices)))
+}
+
+// BulkDeviceGroupAction godoc
+// @Summary Apply an action to every device of a group
+// @Description Enable or disable tracking, send a command, make a community track the devices or change their poll interval. The action is applied device by device, devices the user may not change are reported as failed.
+// @Tags device-groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Device group ID"
+// @Param bulkDeviceAction body schemas.BulkDeviceAction true "Bulk device action"
+// @Success 200 {object} schemas.BulkActionResult
+// @Failure 400 {object} schemas.Error
+// @Failure 403 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/device-groups/{id}/bulk [post]
+func BulkDeviceGroupAction(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
+
+	group, err := GetDeviceGroupFromParam(c, db)
+
+	if err != nil {
+		c.JSON(DeviceGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var schema schemas.BulkDeviceAction
+
+	if err := c.ShouldBindJSON(&schema); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := schema.Validate(); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var community models.Community
+	if schema.CommunityID != nil {
+		if err := community.Fetch(db, schema.CommunityID.String()); err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		if community.ID == uuid.Nil {
+			c.JSON(404, gin.H{"error": "community not found"})
+			return
+		}
+
+		if !community.IsAdmin(user) {
+			c.JSON(403, gin.H{"error": "only admin can add devices to community"})
+			return
+		}
+	}
+
+	devices, err := GroupDevicesWithRole(db, user, group, models.DeviceRoleViewer)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Tracking a device in a community only needs to see it, like CommunityTrackDevice
+	manageable := map[uuid.UUID]bool{}
+	if schema.CommunityID == nil {
+		managed, err := GroupDevicesWithRole(db, user, group, models.DeviceRoleManager)
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		for _, device := range managed {
+			manageable[device.ID] = true
+		}
+	}
+
+	result := schemas.BulkActionResult{Succeeded: []uuid.UUID{}, Failed: []schemas.BulkActionFailure{}}
+
+	for i := range devices {
+		device := &devices[i]
+
+		if schema.CommunityID == nil && !manageable[device.ID] {
+			result.Failed = append(result.Failed, schemas.BulkActionFailure{DeviceID: device.ID, Error: errDeviceForbidden.Error()})
+			continue
+		}
+
+		if err := applyBulkAction(db, user, &schema, &community, device); err != nil {
+			result.Failed = append(result.Failed, schemas.BulkActionFailure{DeviceID: device.ID, Error: err.Error()})
+			continue
+		}
+
+		result.Succeeded = append(result.Succeeded, device.ID)
+	}
+
+	c.JSON(200, result)
+}
+
+func applyBulkAction(db *gorm.DB, user *models.User, action *schemas.BulkDeviceAction, community *models.Community, device *models.GPSDevice) error {
+	switch {
+	case action.Tracking != nil:
+		return db.Model(device).Update("tracking", *action.Tracking).Error
+
+	case action.PollIntervalSeconds != nil:
+		return db.Model(device).Update("poll_interval_seconds", *action.PollIntervalSeconds).Error
+
+	case action.AdaptivePolling != nil:
+		return db.Model(device).Update("adaptive_polling", *action.AdaptivePolling).Error
+
+	case action.Command != nil:
+		definition, err := commands.Find(device.Provider, *action.Command)
+		if err != nil {
+			return err
+		}
+
+		_, err = commands.Queue(db, device, definition, user)
+		return err
+
+	default:
+		// Already tracked devices count as done, the request is about the outcome
+		if community.IsDeviceTracked(device) {
+			return nil
+		}
+
+		return db.Model(community).Association("TrackingDevices").Append(device)
+	}
+}
+
+// GetDeviceGroupPositions godoc
+// @Summary Get latest positions of a device group
+// @Description Get the latest accepted position of every device of a group visible to the user
+// @Tags device-groups
+// @Produce json
+// @Param id path string true "Device group ID"
+// @Success 200 {array} schemas.DevicePosition
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/device-groups/{id}/positions [get]
+func GetDeviceGroupPositions(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
+
+	group, err := GetDeviceGroupFromParam(c, db)
+
+	if err != nil {
+		c.JSON(DeviceGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	devices, err := GroupDevicesWithRole(db, user, group, models.DeviceRoleViewer)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	positions := make([]schemas.DevicePosition, len(devices))
+	if len(devices) == 0 {
+		c.JSON(200, positions)
+		return
+	}
+
+	var locations []models.GPSLocation
+	if err := db.Raw(`
+		SELECT DISTINCT ON (device_id) * FROM gps_locations
+		WHERE device_id IN ? AND filter_reason IS NULL AND deleted_at IS NULL
+		ORDER BY device_id, fix_time DESC
+	`, deviceIDs(devices)).Scan(&locations).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	latest := make(map[uuid.UUID]*models.GPSLocation, len(locations))
+	for i := range locations {
+		latest[locations[i].DeviceID] = &locations[i]
+	}
+
+	for i, device := range devices {
+		positions[i] = schemas.DevicePosition{
+			DeviceID:   device.ID,
+			Name:       device.Name,
+			Status:     device.Status,
+			LastSeenAt: device.LastSeenAt,
+			Location:   latest[device.ID],
+		}
+	}
+
+	c.JSON(200, positions)
+}
+
+// GetDeviceGroupStatus godoc
+// @Summary Get status of a device group
+// @Description Count the devices of a group by status and list the tracked ones needing attention
+// @Tags device-groups
+// @Produce json
+// @Param id path string true "Device group ID"
+// @Success 200 {object} schemas.DeviceGroupStatus
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/device-groups/{id}/status [get]
+func GetDeviceGroupStatus(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
+
+	group, err := GetDeviceGroupFromParam(c, db)
+
+	if err != nil {
+		c.JSON(DeviceGroupErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	devices, err := GroupDevicesWithRole(db, user, group, models.DeviceRoleViewer)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, schemas.ToDeviceGroupStatus(devices, config.GetConfig(db), time.Now().UTC()))
+}
+
+// findVisibleDevices loads the devices of ids, failing if the user can't see one of them
+func findVisibleDevices(db *gorm.DB, user *models.User, ids []uuid.UUID) ([]*models.GPSDevice, error) {
+	devices := []*models.GPSDevice{}
+	if len(ids) == 0 {
+		return devices, nil
+	}
+
+	if err := db.Omit("password").Where("id IN ? AND id IN (?)", ids, models.DevicesWithRole(db, user, models.DeviceRoleViewer)).
+		Find(&devices).Error; err != nil {
+		return nil, err
+	}
+
+	found := make(map[uuid.UUID]bool, len(devices))
+	for _, device := range devices {
+		found[device.ID] = true
+	}
+
+	for _, id := range ids {
+		if !found[id] {
+			return nil, errDeviceNotFound
+		}
+	}
+
+	return devices, nil
+}
+
+func deviceIDs(devices []models.GPSDevice) []uuid.UUID {
+	ids := make([]uuid.UUID, len(devices))
+	for i, device := range devices {
+		ids[i] = device.ID
+	}
+
+	return ids
+}
@@ -0,0 +1,51 @@
+package views
+
+import (
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/config"
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/schemas"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetDevicesNeedingAttention godoc
+// @Summary Get devices needing attention
+// @Description Get tracked devices visible to the user that are offline, never reported, lost their GPS fix, run low on battery or keep failing to poll
+// @Tags devices
+// @Produce json
+// @Success 200 {array} schemas.DeviceAttention
+// @Failure 500 {object} schemas.Error
+// @Router /api/devices/attention [get]
+func GetDevicesNeedingAttention(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
+	conf := config.GetConfig(db)
+
+	now := time.Now().UTC()
+	cutoff := now.Add(-time.Duration(conf.DeviceOfflineMinutes) * time.Minute)
+
+	var devices []models.GPSDevice
+	result := db.Omit("password").
+		Where("tracking = ? AND id IN (?)", true, models.DevicesWithRole(db, user, models.DeviceRoleViewer)).
+		Where(db.Where("status = ?", models.DeviceStatusOffline).
+			Or("last_seen_at IS NULL").
+			Or("last_fix_at IS NULL OR last_fix_at < ?", cutoff).
+			Or("battery < ?", conf.LowBatteryPercent).
+			Or("poll_failures >= ?", conf.AttentionPollFailures)).
+		Order("last_seen_at ASC NULLS FIRST").
+		Find(&devices)
+
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	attention := make([]schemas.DeviceAttention, len(devices))
+	for i, device := range devices {
+		attention[i] = schemas.ToDeviceAttention(device, conf, now)
+	}
+
+	c.JSON(200, attention)
+}
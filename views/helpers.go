@@ -2,12 +2,35 @@ package views
 
 import (
 	"errors"
+	"mime/multipart"
+	"net/http"
 
 	"github.com/Hodik/geo-tracker-be/models"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+var errFileTooLarge = errors.New("file is too large")
+
+// Room for the multipart headers and boundaries around the uploaded file
+const multipartOverhead = 1 << 20
+
+// FormFile returns the file field of a multipart upload of at most maxSize bytes. The body is capped before
+// it is parsed, otherwise the whole upload would be read before its size could be checked.
+func FormFile(c *gin.Context, maxSize int64) (*multipart.FileHeader, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+
+	file, err := c.FormFile("file")
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || (err == nil && file.Size > maxSize) {
+		return nil, errFileTooLarge
+	}
+
+	return file, err
+}
+
 func GetCommunityFromParam(c *gin.Context, db *gorm.DB) (*models.Community, error) {
 	id := c.Param("id")
 
@@ -24,3 +47,149 @@ func GetCommunityFromParam(c *gin.Context, db *gorm.DB) (*models.Community, erro
 
 	return &community, nil
 }
+
+var errDeviceNotFound = errors.New("device not found")
+var errDeviceForbidden = errors.New("insufficient permissions on the device")
+
+// GetGPSDeviceFromParam loads the device of the id param if the user holds at least role on it.
+// Devices the user can't see at all are reported as not found.
+func GetGPSDeviceFromParam(c *gin.Context, db *gorm.DB, role models.DeviceRole) (*models.GPSDevice, error) {
+	user := c.MustGet("user").(*models.User)
+	id := c.Param("id")
+
+	var device models.GPSDevice
+	err := db.Where("id = ?", id).First(&device).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errDeviceNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	canView, err := device.HasRole(db, user, models.DeviceRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canView {
+		return nil, errDeviceNotFound
+	}
+
+	if role != models.DeviceRoleViewer {
+		hasRole, err := device.HasRole(db, user, role)
+		if err != nil {
+			return nil, err
+		}
+
+		if !hasRole {
+			return nil, errDeviceForbidden
+		}
+	}
+
+	return &device, nil
+}
+
+// FilterVisibleDevices keeps the devices the user holds at least the viewer role on
+func FilterVisibleDevices(db *gorm.DB, user *models.User, devices []*models.GPSDevice) ([]*models.GPSDevice, error) {
+	var visibleIDs []uuid.UUID
+	if err := db.Model(&models.GPSDevice{}).Where("id IN (?)", models.DevicesWithRole(db, user, models.DeviceRoleViewer)).
+		Pluck("id", &visibleIDs).Error; err != nil {
+		return nil, err
+	}
+
+	visible := make(map[uuid.UUID]bool, len(visibleIDs))
+	for _, id := range visibleIDs {
+		visible[id] = true
+	}
+
+	filtered := []*models.GPSDevice{}
+	for _, device := range devices {
+		if visible[device.ID] {
+			filtered = append(filtered, device)
+		}
+	}
+
+	return filtered, nil
+}
+
+// DeviceErrorStatus is the response status for an error of GetGPSDeviceFromParam
+func DeviceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errDeviceNotFound):
+		return 404
+	case errors.Is(err, errDeviceForbidden):
+		return 403
+	default:
+		return 500
+	}
+}
+
+// ValidateDeviceAccount checks that the provider account of the device belongs to the user and the same vendor
+func ValidateDeviceAccount(db *gorm.DB, user *models.User, device *models.GPSDevice) error {
+	if device.AccountID == nil {
+		return nil
+	}
+
+	var account models.ProviderAccount
+	err := db.Where("id = ? AND created_by_id = ?", device.AccountID, user.ID).First(&account).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("provider account not found")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if account.Provider != device.Provider {
+		return errors.New("provider account is for another provider")
+	}
+
+	if device.Imei == nil {
+		return errors.New("imei is required to find the device in its provider account")
+	}
+
+	return nil
+}
+
+var errDeviceGroupNotFound = errors.New("device group not found")
+
+// GetDeviceGroupFromParam loads the group of the id param, groups are only visible to their owner
+func GetDeviceGroupFromParam(c *gin.Context, db *gorm.DB) (*models.DeviceGroup, error) {
+	user := c.MustGet("user").(*models.User)
+
+	var group models.DeviceGroup
+	err := db.Where("id = ? AND owner_id = ?", c.Param("id"), user.ID).First(&group).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errDeviceGroupNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+// DeviceGroupErrorStatus is the response status for an error of GetDeviceGroupFromParam
+func DeviceGroupErrorStatus(err error) int {
+	if errors.Is(err, errDeviceGroupNotFound) {
+		return 404
+	}
+
+	return 500
+}
+
+// GroupDevicesWithRole loads the members of the group the user holds at least role on.
+// Members whose share expired since they were added are left out rather than reported.
+func GroupDevicesWithRole(db *gorm.DB, user *models.User, group *models.DeviceGroup, role models.DeviceRole) ([]models.GPSDevice, error) {
+	var devices []models.GPSDevice
+	err := db.Omit("password").
+		Where("id IN (?) AND id IN (?)", group.MemberIDs(db), models.DevicesWithRole(db, user, role)).
+		Order("created_at").Find(&devices).Error
+
+	return devices, err
+}
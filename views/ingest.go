@@ -0,0 +1,127 @@
+package views
+
+import (
+	"errors"
+
+	"github.com/Hodik/geo-tracker-be/ingestion"
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/schemas"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// OsmAndIngest godoc
+// @Summary Push a location in OsmAnd format
+// @Description Store a single location sent by the OsmAnd or Traccar Client. The id parameter is the ingest token of the device.
+// @Tags ingest
+// @Produce json
+// @Param id query string true "Device ingest token"
+// @Param lat query number true "Latitude"
+// @Param lon query number true "Longitude"
+// @Param timestamp query string false "Unix seconds, unix milliseconds or ISO 8601 time"
+// @Param speed query number false "Speed"
+// @Param bearing query number false "Bearing"
+// @Param altitude query number false "Altitude"
+// @Param accuracy query number false "Accuracy in meters"
+// @Param batt query number false "Battery level"
+// @Success 200
+// @Failure 400 {object} schemas.Error
+// @Failure 401 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /ingest/osmand [get]
+// @Router /ingest/osmand [post]
+func OsmAndIngest(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	var schema schemas.OsmAndLocation
+
+	if err := c.ShouldBind(&schema); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	device, err := getIngestDevice(db, schema.ID)
+
+	if err != nil {
+		c.JSON(401, gin.H{"error": err.Error()})
+		return
+	}
+
+	location, err := schema.ToGPSLocation()
+
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ingestion.StoreLocations(db, device, []*models.GPSLocation{location}); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(200)
+}
+
+// OsmAndIngestBatch godoc
+// @Summary Push buffered locations in OsmAnd format
+// @Description Store a batch of locations recorded while the phone was offline. Invalid points reject the whole batch.
+// @Tags ingest
+// @Accept json
+// @Produce json
+// @Param batch body schemas.OsmAndBatch true "Buffered locations"
+// @Success 200
+// @Failure 400 {object} schemas.Error
+// @Failure 401 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /ingest/osmand/batch [post]
+func OsmAndIngestBatch(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	var schema schemas.OsmAndBatch
+
+	if err := c.ShouldBindJSON(&schema); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	device, err := getIngestDevice(db, schema.ID)
+
+	if err != nil {
+		c.JSON(401, gin.H{"error": err.Error()})
+		return
+	}
+
+	locations := make([]*models.GPSLocation, 0, len(schema.Locations))
+	for _, item := range schema.Locations {
+		location, err := item.ToGPSLocation()
+
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		locations = append(locations, location)
+	}
+
+	if err := ingestion.StoreLocations(db, device, locations); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(200)
+}
+
+func getIngestDevice(db *gorm.DB, token string) (*models.GPSDevice, error) {
+	if token == "" {
+		return nil, errors.New("device token is required")
+	}
+
+	var device models.GPSDevice
+	result := db.Where("ingest_token = ? AND provider = ?", token, models.ProviderOsmAnd).First(&device)
+
+	if result.Error != nil {
+		return nil, errors.New("invalid device token")
+	}
+
+	return &device, nil
+}
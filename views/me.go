@@ -26,10 +26,18 @@ func GetMe(c *gin.Context) {
 	userSettings, err := models.GetUserSettings(db, user)
 
 	if err != nil {
-		c.JSON(500, gin.H{"error": err})
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
 	}
 
-	c.JSON(200, schemas.ToUserProfile(user, userSettings))
+	profile, err := userProfile(db, user, userSettings)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, profile)
 }
 
 // UpdateMe godoc
@@ -57,7 +65,8 @@ func UpdateMe(c *gin.Context) {
 	userSettings, err := models.GetUserSettings(db, user)
 
 	if err != nil {
-		c.JSON(500, gin.H{"error": err})
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
 	}
 
 	updateMe.ToUser(user)
@@ -69,7 +78,14 @@ func UpdateMe(c *gin.Context) {
 		return
 	}
 
-	c.JSON(200, schemas.ToUserProfile(user, userSettings))
+	profile, err := userProfile(db, user, userSettings)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, profile)
 }
 
 // CreateAreaOfInterest godoc
@@ -208,6 +224,18 @@ func UserTrackDevice(c *gin.Context) {
 		return
 	}
 
+	canView, err := device.HasRole(db, user, models.DeviceRoleViewer)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !canView {
+		c.JSON(404, gin.H{"error": "device not found"})
+		return
+	}
+
 	userSettings, err := models.GetUserSettings(db, user)
 
 	if err != nil {
@@ -225,7 +253,14 @@ func UserTrackDevice(c *gin.Context) {
 		return
 	}
 
-	c.JSON(200, schemas.ToUserProfile(user, userSettings))
+	profile, err := userProfile(db, user, userSettings)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, profile)
 }
 
 // UserUntrackDevice godoc
@@ -271,7 +306,14 @@ func UserUntrackDevice(c *gin.Context) {
 		return
 	}
 
-	c.JSON(200, schemas.ToUserProfile(user, userSettings))
+	profile, err := userProfile(db, user, userSettings)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, profile)
 }
 
 // GetMyCommunities godoc
@@ -356,3 +398,15 @@ func MyFeed(c *gin.Context) {
 	paginated := schemas.Paginated{Page: page, PageSize: pageSize, Total: int(total), Items: events}
 	c.JSON(200, paginated)
 }
+
+// userProfile hides tracked devices that are no longer shared with the user. The tracking itself is kept, so
+// the device shows up again when it is shared again, and can still be untracked.
+func userProfile(db *gorm.DB, user *models.User, settings *models.UserSettings) (*schemas.UserProfile, error) {
+	visible, err := FilterVisibleDevices(db, user, settings.TrackingDevices)
+	if err != nil {
+		return nil, err
+	}
+
+	settings.TrackingDevices = visible
+	return schemas.ToUserProfile(user, settings), nil
+}
@@ -0,0 +1,234 @@
+package views
+
+import (
+	"fmt"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/schemas"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const maxDeviceCSVUploadSize = 4 << 20
+
+// ImportGPSDevices godoc
+// @Summary Import GPS devices in bulk
+// @Description Create many devices from a CSV file with a header row named like the JSON fields, or from a JSON list. Every row is validated, IMEIs and phone numbers must be valid and unused. Valid rows are created together, invalid rows are reported with their errors. With dry_run nothing is created.
+// @Tags devices
+// @Accept json
+// @Accept multipart/form-data
+// @Produce json
+// @Param deviceImport body schemas.DeviceImport false "Devices to import"
+// @Param file formData file false "CSV file"
+// @Param dry_run query bool false "Only validate the rows"
+// @Param community_id query string false "Community tracking the created devices"
+// @Success 200 {object} schemas.DeviceImportResult
+// @Success 201 {object} schemas.DeviceImportResult
+// @Failure 400 {object} schemas.Error
+// @Failure 403 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/devices/import [post]
+func ImportGPSDevices(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
+
+	var query schemas.DeviceImportQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var community models.Community
+	if query.CommunityID != nil {
+		id, err := uuid.Parse(*query.CommunityID)
+
+		if err != nil {
+			c.JSON(400, gin.H{"error": "invalid community_id"})
+			return
+		}
+
+		if err := community.Fetch(db, id.String()); err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+
+		if community.ID == uuid.Nil {
+			c.JSON(404, gin.H{"error": "community not found"})
+			return
+		}
+
+		if !community.IsAdmin(user) {
+			c.JSON(403, gin.H{"error": "only admin can add devices to community"})
+			return
+		}
+	}
+
+	devices, rowErrors, err := bindDeviceImport(c)
+
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(devices) == 0 {
+		c.JSON(400, gin.H{"error": "no devices to import"})
+		return
+	}
+
+	takenImeis, takenNumbers, err := takenDeviceIdentifiers(db, devices)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := schemas.DeviceImportResult{DryRun: query.DryRun, Rows: make([]schemas.DeviceImportRow, len(devices))}
+	seenImeis := map[string]int{}
+	seenNumbers := map[string]int{}
+	created := []*models.GPSDevice{}
+	createdRows := []int{}
+
+	for i := range devices {
+		schema := &devices[i]
+		row := schemas.DeviceImportRow{Row: i + 1, Imei: schema.Imei, Number: schema.Number, Errors: []string{}}
+		row.Errors = append(row.Errors, rowErrors[row.Row]...)
+		row.Errors = append(row.Errors, schema.ValidateImport()...)
+
+		if schema.Imei != nil {
+			if takenImeis[*schema.Imei] {
+				row.Errors = append(row.Errors, "imei is already registered")
+			} else if first, ok := seenImeis[*schema.Imei]; ok {
+				row.Errors = append(row.Errors, fmt.Sprintf("imei is already used on row %d", first))
+			} else {
+				seenImeis[*schema.Imei] = row.Row
+			}
+		}
+
+		if schema.Number != nil {
+			if takenNumbers[*schema.Number] {
+				row.Errors = append(row.Errors, "number is already registered")
+			} else if first, ok := seenNumbers[*schema.Number]; ok {
+				row.Errors = append(row.Errors, fmt.Sprintf("number is already used on row %d", first))
+			} else {
+				seenNumbers[*schema.Number] = row.Row
+			}
+		}
+
+		device, err := schema.ToGPSDevice(user)
+		if err != nil {
+			row.Errors = append(row.Errors, err.Error())
+		} else if err := ValidateDeviceAccount(db, user, device); err != nil {
+			row.Errors = append(row.Errors, err.Error())
+		}
+
+		result.Rows[i] = row
+
+		if len(row.Errors) > 0 {
+			result.Invalid++
+			continue
+		}
+
+		result.Valid++
+		created = append(created, device)
+		createdRows = append(createdRows, i)
+	}
+
+	if query.DryRun || len(created) == 0 {
+		c.JSON(200, result)
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(created).Error; err != nil {
+			return err
+		}
+
+		if query.CommunityID == nil {
+			return nil
+		}
+
+		return tx.Model(&community).Association("TrackingDevices").Append(created)
+	})
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	for i, device := range created {
+		result.Rows[createdRows[i]].DeviceID = &device.ID
+	}
+	result.Created = len(created)
+
+	c.JSON(201, result)
+}
+
+// bindDeviceImport reads the devices of a CSV upload or a JSON body, with the errors of cells that couldn't be parsed
+func bindDeviceImport(c *gin.Context) ([]schemas.CreateGPSDevice, map[int][]string, error) {
+	if c.ContentType() != "multipart/form-data" {
+		var schema schemas.DeviceImport
+
+		if err := c.ShouldBindJSON(&schema); err != nil {
+			return nil, nil, err
+		}
+
+		if len(schema.Devices) > schemas.MaxDeviceImportRows {
+			return nil, nil, fmt.Errorf("at most %d devices can be imported at once", schemas.MaxDeviceImportRows)
+		}
+
+		return schema.Devices, nil, nil
+	}
+
+	file, err := FormFile(c, maxDeviceCSVUploadSize)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	openedFile, err := file.Open()
+
+	if err != nil {
+		return nil, nil, err
+	}
+	defer openedFile.Close()
+
+	return schemas.ParseDeviceCSV(openedFile)
+}
+
+// takenDeviceIdentifiers finds the IMEIs and numbers of the import already in use. Deleted devices
+// still hold theirs, the unique indexes cover them too.
+func takenDeviceIdentifiers(db *gorm.DB, devices []schemas.CreateGPSDevice) (map[string]bool, map[string]bool, error) {
+	imeis := []string{}
+	numbers := []string{}
+	for _, device := range devices {
+		if device.Imei != nil {
+			imeis = append(imeis, *device.Imei)
+		}
+
+		if device.Number != nil {
+			numbers = append(numbers, *device.Number)
+		}
+	}
+
+	var existing []models.GPSDevice
+	if err := db.Unscoped().Select("imei", "number").Where("imei IN ? OR number IN ?", imeis, numbers).Find(&existing).Error; err != nil {
+		return nil, nil, err
+	}
+
+	takenImeis := map[string]bool{}
+	takenNumbers := map[string]bool{}
+	for _, device := range existing {
+		if device.Imei != nil {
+			takenImeis[*device.Imei] = true
+		}
+
+		if device.Number != nil {
+			takenNumbers[*device.Number] = true
+		}
+	}
+
+	return takenImeis, takenNumbers, nil
+}
@@ -0,0 +1,223 @@
+package views
+
+import (
+	"errors"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/schemas"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetGPSDeviceShares godoc
+// @Summary Get shares of a GPS device
+// @Description Get the users and communities a device is shared with, including expired shares
+// @Tags devices
+// @Produce json
+// @Param id path string true "Device ID"
+// @Success 200 {array} models.DeviceShare
+// @Failure 403 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/devices/{id}/shares [get]
+func GetGPSDeviceShares(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	device, err := GetGPSDeviceFromParam(c, db, models.DeviceRoleManager)
+
+	if err != nil {
+		c.JSON(DeviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var shares []models.DeviceShare
+	if err := db.Preload("User").Where("device_id = ?", device.ID).Order("created_at").Find(&shares).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, shares)
+}
+
+// CreateGPSDeviceShare godoc
+// @Summary Share a GPS device
+// @Description Share a device with a user or with every member of a community, as viewer or manager and optionally until a given time. Only the owner can share a device.
+// @Tags devices
+// @Accept json
+// @Produce json
+// @Param id path string true "Device ID"
+// @Param createDeviceShare body schemas.CreateDeviceShare true "Create device share"
+// @Success 201 {object} models.DeviceShare
+// @Failure 400 {object} schemas.Error
+// @Failure 403 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/devices/{id}/shares [post]
+func CreateGPSDeviceShare(c *gin.Context) {
+	user := c.MustGet("user").(*models.User)
+	db := c.MustGet("db").(*gorm.DB)
+
+	device, err := GetGPSDeviceFromParam(c, db, models.DeviceRoleOwner)
+
+	if err != nil {
+		c.JSON(DeviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var schema schemas.CreateDeviceShare
+
+	if err := c.ShouldBindJSON(&schema); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	share, err := schema.ToDeviceShare(device, user)
+
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var count int64
+	if share.UserID != nil {
+		err = db.Model(&models.User{}).Where("id = ?", share.UserID).Count(&count).Error
+	} else {
+		err = db.Model(&models.Community{}).Where("id = ?", share.CommunityID).Count(&count).Error
+	}
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	if count == 0 {
+		c.JSON(404, gin.H{"error": "user or community not found"})
+		return
+	}
+
+	existing := db.Model(&models.DeviceShare{}).Where("device_id = ?", device.ID)
+	if share.UserID != nil {
+		existing = existing.Where("user_id = ?", share.UserID)
+	} else {
+		existing = existing.Where("community_id = ?", share.CommunityID)
+	}
+
+	if err := existing.Count(&count).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	if count > 0 {
+		c.JSON(400, gin.H{"error": "device is already shared with this user or community, update the share instead"})
+		return
+	}
+
+	if err := db.Create(share).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(201, share)
+}
+
+// UpdateGPSDeviceShare godoc
+// @Summary Update a share of a GPS device
+// @Description Change the role or the expiry of a share. Only the owner can change shares.
+// @Tags devices
+// @Accept json
+// @Produce json
+// @Param id path string true "Device ID"
+// @Param share_id path string true "Share ID"
+// @Param updateDeviceShare body schemas.UpdateDeviceShare true "Update device share"
+// @Success 200 {object} models.DeviceShare
+// @Failure 400 {object} schemas.Error
+// @Failure 403 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/devices/{id}/shares/{share_id} [patch]
+func UpdateGPSDeviceShare(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	device, err := GetGPSDeviceFromParam(c, db, models.DeviceRoleOwner)
+
+	if err != nil {
+		c.JSON(DeviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var schema schemas.UpdateDeviceShare
+
+	if err := c.ShouldBindJSON(&schema); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var share models.DeviceShare
+	result := db.Where("id = ? AND device_id = ?", c.Param("share_id"), device.ID).First(&share)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "share not found"})
+		return
+	}
+
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	if err := schema.ToDeviceShare(&share); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.Save(&share).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, share)
+}
+
+// DeleteGPSDeviceShare godoc
+// @Summary Revoke a share of a GPS device
+// @Description Revoke a share immediately. Only the owner can revoke shares.
+// @Tags devices
+// @Produce json
+// @Param id path string true "Device ID"
+// @Param share_id path string true "Share ID"
+// @Success 204
+// @Failure 403 {object} schemas.Error
+// @Failure 404 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/devices/{id}/shares/{share_id} [delete]
+func DeleteGPSDeviceShare(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	device, err := GetGPSDeviceFromParam(c, db, models.DeviceRoleOwner)
+
+	if err != nil {
+		c.JSON(DeviceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	var share models.DeviceShare
+	result := db.Where("id = ? AND device_id = ?", c.Param("share_id"), device.ID).First(&share)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		c.JSON(404, gin.H{"error": "share not found"})
+		return
+	}
+
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	// Hard delete, a soft deleted row would keep the device from being shared with the same target again
+	if err := db.Unscoped().Delete(&share).Error; err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(204)
+}
@@ -0,0 +1,203 @@
+package views
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/Hodik/geo-tracker-be/realtime"
+	"github.com/Hodik/geo-tracker-be/schemas"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Idle streams are kept alive so proxies don't close them
+const streamKeepAlive = 25 * time.Second
+
+func subscribeStream(c *gin.Context) (*realtime.Subscription, bool) {
+	db := c.MustGet("db").(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
+
+	var query schemas.StreamQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	deviceIDs, err := query.DeviceIDs()
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	communityIDs, err := query.CommunityIDs()
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	bbox, err := query.BoundingBox()
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	filter, err := realtime.NewFilter(db, user, deviceIDs, communityIDs, bbox)
+	if errors.Is(err, realtime.ErrNotTracked) || errors.Is(err, realtime.ErrNotMember) {
+		c.JSON(403, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	return realtime.Subscribe(filter), true
+}
+
+// StreamEvents godoc
+// @Summary Stream changes as Server-Sent Events
+// @Description Stream new locations, device status changes, events and comments. Without filters the stream follows every device visible to the user and every community of the user. Browsers unable to set the Authorization header pass a ticket from POST /api/stream/ticket instead.
+// @Tags stream
+// @Produce text/event-stream
+// @Param devices query string false "Comma separated IDs of devices visible to the user"
+// @Param communities query string false "Comma separated IDs of communities of the user"
+// @Param bbox query string false "Area as min_lon,min_lat,max_lon,max_lat"
+// @Param ticket query string false "Single use ticket, for clients unable to set the Authorization header"
+// @Success 200 {object} realtime.Message
+// @Failure 400 {object} schemas.Error
+// @Failure 403 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/stream [get]
+func StreamEvents(c *gin.Context) {
+	subscription, ok := subscribeStream(c)
+	if !ok {
+		return
+	}
+	defer realtime.Unsubscribe(subscription)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(200)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+
+		case <-keepAlive.C:
+			if _, err := io.WriteString(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+
+		case message, ok := <-subscription.Messages:
+			if !ok {
+				return
+			}
+
+			c.SSEvent(string(message.Type), message)
+			c.Writer.Flush()
+		}
+	}
+}
+
+// StreamWebSocket godoc
+// @Summary Stream changes over a WebSocket
+// @Description Same stream as /api/stream, every message is a JSON text frame. Browsers unable to set the Authorization header pass a ticket from POST /api/stream/ticket instead.
+// @Tags stream
+// @Param devices query string false "Comma separated IDs of devices visible to the user"
+// @Param communities query string false "Comma separated IDs of communities of the user"
+// @Param bbox query string false "Area as min_lon,min_lat,max_lon,max_lat"
+// @Param ticket query string false "Single use ticket, for clients unable to set the Authorization header"
+// @Success 101 {object} realtime.Message
+// @Failure 400 {object} schemas.Error
+// @Failure 403 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Router /api/stream/ws [get]
+func StreamWebSocket(c *gin.Context) {
+	subscription, ok := subscribeStream(c)
+	if !ok {
+		return
+	}
+	defer realtime.Unsubscribe(subscription)
+
+	ws, err := realtime.UpgradeWebSocket(c.Writer, c.Request)
+	if errors.Is(err, realtime.ErrNotWebSocket) {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		log.Println("Failed to open websocket", err)
+		return
+	}
+	defer ws.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		ws.ReadLoop()
+		close(closed)
+	}()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+
+		case <-keepAlive.C:
+			if err := ws.Ping(); err != nil {
+				return
+			}
+
+		case message, ok := <-subscription.Messages:
+			if !ok {
+				return
+			}
+
+			payload, err := json.Marshal(message)
+			if err != nil {
+				log.Println("Failed to encode stream message", err)
+				continue
+			}
+
+			if err := ws.WriteText(payload); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// CreateStreamTicket godoc
+// @Summary Create a stream ticket
+// @Description Create a single use ticket opening a stream within 30 seconds, for clients unable to set the Authorization header on stream requests. Access tokens must never be put in URLs.
+// @Tags stream
+// @Produce json
+// @Success 201 {object} schemas.StreamTicket
+// @Failure 500 {object} schemas.Error
+// @Router /api/stream/ticket [post]
+func CreateStreamTicket(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
+
+	ticket, expiresAt, err := models.IssueStreamTicket(db, user)
+
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(201, schemas.StreamTicket{Ticket: ticket, ExpiresAt: expiresAt})
+}
@@ -0,0 +1,122 @@
+package views
+
+import (
+	"errors"
+	"log"
+	"regexp"
+
+	"github.com/Hodik/geo-tracker-be/commands"
+	"github.com/Hodik/geo-tracker-be/ingestion"
+	"github.com/Hodik/geo-tracker-be/messaging"
+	"github.com/Hodik/geo-tracker-be/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Twilio replies the TwiML to the sender, an empty response sends nothing back to the tracker
+const emptyTwiML = "<?xml version=\"1.0\" encoding=\"UTF-8\"?><Response></Response>"
+
+var phoneNumberCleanup = regexp.MustCompile(`[^0-9+]`)
+
+// TwilioSMSWebhook godoc
+// @Summary Receive an SMS from a tracker
+// @Description Twilio webhook for inbound SMS. Replies of trackers with a known number are parsed for a position, which is stored as a location of the device.
+// @Tags webhooks
+// @Accept x-www-form-urlencoded
+// @Produce xml
+// @Param X-Twilio-Signature header string true "Twilio request signature"
+// @Param From formData string true "Sender phone number"
+// @Param Body formData string true "Message text"
+// @Success 200
+// @Failure 403 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Failure 503 {object} schemas.Error
+// @Router /webhooks/twilio/sms [post]
+func TwilioSMSWebhook(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	if !messaging.IsConfigured() {
+		c.JSON(503, gin.H{"error": "sms is not configured"})
+		return
+	}
+
+	if err := messaging.ValidateWebhook(c); err != nil {
+		c.JSON(403, gin.H{"error": err.Error()})
+		return
+	}
+
+	from := phoneNumberCleanup.ReplaceAllString(c.PostForm("From"), "")
+	body := c.PostForm("Body")
+
+	var device models.GPSDevice
+	result := db.Where("regexp_replace(number, '[^0-9+]', '', 'g') = ?", from).First(&device)
+
+	// Twilio retries failed webhooks, messages we can't use are acknowledged and dropped
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		log.Println("Received SMS from unknown number", from)
+		c.Data(200, "application/xml", []byte(emptyTwiML))
+		return
+	}
+
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": result.Error.Error()})
+		return
+	}
+
+	// A reply matching a waiting command tells us the device received it, location replies are stored on top
+	if _, err := commands.Acknowledge(db, &device, body); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	location, err := messaging.ParseLocation(body)
+
+	if err != nil {
+		log.Println("Received SMS without location from device", device.ID, err)
+		c.Data(200, "application/xml", []byte(emptyTwiML))
+		return
+	}
+
+	if err := ingestion.StoreLocations(db, &device, []*models.GPSLocation{location}); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(200, "application/xml", []byte(emptyTwiML))
+}
+
+// TwilioStatusWebhook godoc
+// @Summary Receive the delivery status of an SMS
+// @Description Twilio status callback for outbound SMS. Delivery reports update the device command sent with the message.
+// @Tags webhooks
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param X-Twilio-Signature header string true "Twilio request signature"
+// @Param MessageSid formData string true "Message SID"
+// @Param MessageStatus formData string true "Message status"
+// @Param ErrorCode formData string false "Twilio error code"
+// @Success 204
+// @Failure 403 {object} schemas.Error
+// @Failure 500 {object} schemas.Error
+// @Failure 503 {object} schemas.Error
+// @Router /webhooks/twilio/status [post]
+func TwilioStatusWebhook(c *gin.Context) {
+	db := c.MustGet("db").(*gorm.DB)
+
+	if !messaging.IsConfigured() {
+		c.JSON(503, gin.H{"error": "sms is not configured"})
+		return
+	}
+
+	if err := messaging.ValidateStatusCallback(c); err != nil {
+		c.JSON(403, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := commands.UpdateDeliveryStatus(db, c.PostForm("MessageSid"), c.PostForm("MessageStatus"), c.PostForm("ErrorCode")); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(204)
+}
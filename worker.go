@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Hodik/geo-tracker-be/coordination"
+	"github.com/Hodik/geo-tracker-be/dbconn"
+	"gorm.io/gorm"
+)
+
+// runWorker runs the background jobs until SIGTERM, then waits for the jobs to finish their current run.
+// Any number of workers can run side by side, devices are spread over them and scheduled jobs run on the leader.
+func runWorker() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	db := dbconn.GetDB()
+
+	replica := coordination.NewReplica(db)
+	replica.Join(ctx)
+
+	// The replica stays registered until the jobs are done, so its devices aren't picked up mid-poll
+	replicaCtx, leave := context.WithCancel(context.Background())
+	replicaDone := make(chan struct{})
+	go func() {
+		replica.Run(replicaCtx)
+		close(replicaDone)
+	}()
+
+	log.Println("Worker", replica.ID, "started")
+
+	jobs := []func(ctx context.Context, db *gorm.DB){
+		func(ctx context.Context, db *gorm.DB) { ScheduleRetention(ctx, db, replica) },
+		func(ctx context.Context, db *gorm.DB) { ScheduleTripSegmentation(ctx, db, replica) },
+		func(ctx context.Context, db *gorm.DB) { ScheduleStats(ctx, db, replica) },
+		func(ctx context.Context, db *gorm.DB) { ScheduleHealth(ctx, db, replica) },
+		ScheduleCommandDispatch,
+		func(ctx context.Context, db *gorm.DB) { PollDevices(ctx, db, replica) },
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job(ctx, db)
+		}()
+	}
+
+	<-ctx.Done()
+	log.Println("Shutting down worker")
+
+	wg.Wait()
+
+	leave()
+	<-replicaDone
+
+	log.Println("Worker stopped")
+}
+
+// sleepContext waits for d and reports false when ctx was cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}